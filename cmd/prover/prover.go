@@ -1,12 +1,29 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"net"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
 
 	"github.com/iden3/prover-server/pkg/app"
 	"github.com/iden3/prover-server/pkg/app/configs"
 	"github.com/iden3/prover-server/pkg/app/handlers"
+	"github.com/iden3/prover-server/pkg/app/rpc"
+	"github.com/iden3/prover-server/pkg/app/tlsreload"
+	"github.com/iden3/prover-server/pkg/circuits"
+	"github.com/iden3/prover-server/pkg/cluster"
+	"github.com/iden3/prover-server/pkg/idempotency"
+	"github.com/iden3/prover-server/pkg/jobs"
 	"github.com/iden3/prover-server/pkg/log"
+	"github.com/iden3/prover-server/pkg/scheduler"
+	"github.com/iden3/prover-server/pkg/worker"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 func main() {
@@ -17,17 +34,235 @@ func main() {
 		os.Exit(1)
 	}
 
-	log.SetLevelStr(config.Log.Level)
+	if err = log.SetLevelStr(config.Log.Level); err != nil {
+		log.Errorw("can't change log level", "error", err)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == worker.Command {
+		if err = worker.Serve(); err != nil {
+			log.Errorw("worker failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// server is shut down on interrupt, so that job store is closed cleanly
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// init handlers for router
 
+	var idempotencyStore *idempotency.Store
+	if config.Idempotency.TTL > 0 {
+		idempotencyStore = idempotency.NewStore(config.Idempotency.TTL)
+	}
+
+	circuitRegistry, err := circuits.NewRegistry(config.Prover)
+	if err != nil {
+		log.Errorw("cannot load circuits", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := circuitRegistry.Close(); err != nil {
+			log.Errorw("cannot remove circuit snapshots", "error", err)
+		}
+	}()
+
+	if config.Prover.Remote.Prefetch {
+		go circuitRegistry.Prefetch(context.Background())
+	}
+
+	if config.Prover.WatchCircuits {
+		if err = circuitRegistry.Watch(context.Background(), config.Prover.WatchDebounce); err != nil {
+			log.Errorw("cannot watch circuits", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	zkHandler := handlers.NewZKHandler(circuitRegistry, idempotencyStore)
+
+	if config.Prover.Workers.Count > 0 {
+		executable, err := os.Executable()
+		if err != nil {
+			log.Errorw("cannot find executable of workers", "error", err)
+			os.Exit(1)
+		}
+		pool, err := worker.NewPool(config.Prover.Workers.Count, config.Prover.Memory.ArtifactCacheMB<<20, executable,
+			worker.Command)
+		if err != nil {
+			log.Errorw("cannot start workers", "error", err)
+			os.Exit(1)
+		}
+		zkHandler.WithWorkers(pool)
+	}
+	// proofs are queued in priority lanes when they are limited by memory budget or number of workers,
+	// coordinator limits them by capacity of workers of the cluster instead
+	if !config.Cluster.Coordinator && (config.Prover.Memory.BudgetMB > 0 || config.Prover.Workers.Count > 0) {
+		queue := scheduler.NewBudget(config.Prover.Memory.BudgetMB << 20).
+			WithConcurrency(config.Prover.Workers.Count).
+			WithMaxWait(config.Prover.Priority.MaxWait)
+		zkHandler.WithQueue(queue)
+	}
+	var clusterHandler *handlers.ClusterHandler
+	if config.Cluster.Coordinator {
+		// workers registered by heartbeats receive private inputs of proofs
+		if config.Cluster.Token == "" {
+			log.Errorw("cannot start coordinator", "error", "cluster.token is required in coordinator mode")
+			os.Exit(1)
+		}
+		coordinator, err := cluster.NewCoordinator(config.Cluster)
+		if err != nil {
+			log.Errorw("cannot start coordinator", "error", err)
+			os.Exit(1)
+		}
+		zkHandler.WithCluster(coordinator)
+		clusterHandler = handlers.NewClusterHandler(coordinator, config.Cluster.Token)
+	}
+	if config.Cluster.CoordinatorURL != "" {
+		clusterConfig := config.Cluster
+		if clusterConfig.Capacity == 0 {
+			clusterConfig.Capacity = config.Prover.Workers.Count
+		}
+		member, err := cluster.NewMember(clusterConfig, zkHandler.ListCircuits)
+		if err != nil {
+			log.Errorw("cannot join cluster", "error", err)
+			os.Exit(1)
+		}
+		go member.Run(context.Background())
+	}
+
+	priorities, err := scheduler.NewResolver(config.Prover.Priority)
+	if err != nil {
+		log.Errorw("cannot init priorities", "error", err)
+		os.Exit(1)
+	}
+
+	var jobStore jobs.Store = jobs.NewMemoryStore()
+	if config.Jobs.StorePath != "" {
+		jobStore, err = jobs.NewBoltStore(config.Jobs.StorePath)
+		if err != nil {
+			log.Errorw("cannot open job store", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	defer func() {
+		if err := jobStore.Close(); err != nil {
+			log.Errorw("cannot close job store", "error", err)
+		}
+	}()
+
+	jobManager := jobs.NewManager(jobStore, zkHandler.Generate, jobs.Options{
+		Workers:   config.Jobs.Workers,
+		Retention: config.Jobs.Retention,
+		Webhooks:  jobs.WebhookOptions(config.Jobs.Webhooks),
+	})
+	if err = jobManager.Start(ctx); err != nil {
+		log.Errorw("cannot start job manager", "error", err)
+		os.Exit(1)
+	}
+
 	var appHandlers = app.Handlers{
-		ZKHandler: handlers.NewZKHandler(config.Prover),
+		ZKHandler:        zkHandler,
+		JobsHandler:      handlers.NewJobsHandler(circuitRegistry, jobManager),
+		ClusterHandler:   clusterHandler,
+		AllowedClientCNs: config.Server.TLS.AllowedClientCNs,
+		Priorities:       priorities,
 	}
 	router := appHandlers.Routes()
 
 	server := app.NewServer(router)
 
+	var tlsConfig *tls.Config
+	var grpcOpts []grpc.ServerOption
+	if err = validateTLSConfig(config.Server.TLS); err != nil {
+		log.Errorw("illegal TLS config", "error", err)
+		os.Exit(1)
+	}
+	if config.Server.TLS.CertFile != "" {
+		tlsConfig, err = newTLSConfig(config.Server.TLS)
+		if err != nil {
+			log.Errorw("cannot init TLS", "error", err)
+			os.Exit(1)
+		}
+		server.WithTLS(tlsConfig)
+		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	if config.GRPC.Enabled {
+		var grpcAddress string
+		if config.GRPC.Port != 0 {
+			grpcAddress = net.JoinHostPort(config.Server.Host, strconv.Itoa(config.GRPC.Port))
+		}
+		// client certificates are checked by interceptors, gRPC calls don't pass through REST middlewares
+		grpcOpts = append(grpcOpts, rpc.RequireClientCN(config.Server.TLS.AllowedClientCNs)...)
+		grpcOpts = append(grpcOpts, rpc.WithPriorities(priorities)...)
+		server.WithGRPC(rpc.NewServer(zkHandler, grpcOpts...), grpcAddress)
+	}
+
+	addresses := []string{net.JoinHostPort(config.Server.Host, strconv.Itoa(config.Server.Port))}
+	if config.Server.Socket != "" {
+		addresses = append(addresses, "unix:"+config.Server.Socket)
+	}
+	addresses = append(addresses, config.Server.Listen...)
+
+	if len(config.Admin.Listen) > 0 {
+		if config.Admin.Password == "" {
+			log.Errorw("admin API requires admin.password")
+			os.Exit(1)
+		}
+		adminHandlers := app.AdminHandlers{
+			AdminHandler: handlers.NewAdminHandler(zkHandler, jobManager),
+			Username:     config.Admin.Username,
+			Password:     config.Admin.Password,
+		}
+		adminServer := app.NewServer(adminHandlers.Routes())
+		if tlsConfig != nil {
+			adminServer.WithTLS(tlsConfig)
+		}
+		go func() {
+			if err := adminServer.Run(ctx, config.Admin.Listen...); err != nil {
+				log.Errorw("admin server failed", "error", err)
+				stop()
+			}
+		}()
+	}
+
 	// start the server
-	server.Run(config.Server.Port)
+	if err = server.Run(ctx, addresses...); err != nil {
+		log.Errorw("server failed", "error", err)
+	}
+
+}
+
+// validateTLSConfig rejects client certificate settings which would verify clients against system roots
+// or lock all clients out
+func validateTLSConfig(tlsCfg configs.TLSConfig) error {
+	clientAuth, err := tlsreload.ParseClientAuth(tlsCfg.ClientAuth)
+	if err != nil {
+		return err
+	}
+	if clientAuth != tls.NoClientCert && tlsCfg.ClientCAFile == "" {
+		return errors.New("clientAuth requires clientCAFile")
+	}
+	if len(tlsCfg.AllowedClientCNs) > 0 && (clientAuth == tls.NoClientCert || tlsCfg.CertFile == "") {
+		return errors.New("allowedClientCNs requires certFile and clientAuth")
+	}
+	return nil
+}
+
+// newTLSConfig loads certificates and starts watching them for changes
+func newTLSConfig(tlsCfg configs.TLSConfig) (*tls.Config, error) {
+	clientAuth, err := tlsreload.ParseClientAuth(tlsCfg.ClientAuth)
+	if err != nil {
+		return nil, err
+	}
+
+	reloader, err := tlsreload.New(tlsCfg.CertFile, tlsCfg.KeyFile, tlsCfg.ClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	go reloader.Watch(context.Background(), tlsCfg.ReloadInterval)
 
+	return reloader.TLSConfig(clientAuth), nil
 }
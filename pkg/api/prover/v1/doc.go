@@ -0,0 +1,7 @@
+// Package proverv1 contains generated code of the prover gRPC API defined in api/proto/prover/v1/prover.proto
+package proverv1
+
+// Code is generated by protoc 23.4 with protoc-gen-go v1.30.0 and protoc-gen-go-grpc v1.3.0, output paths are
+// derived from go_package of the proto file relative to the module root.
+//go:generate sh -c "protoc --version | grep -qx 'libprotoc 23.4' || { echo 'protoc 23.4 is required' >&2; exit 1; }"
+//go:generate protoc -I ../../../../api/proto --go_out=../../../.. --go_opt=module=github.com/iden3/prover-server --go-grpc_out=../../../.. --go-grpc_opt=module=github.com/iden3/prover-server prover/v1/prover.proto
@@ -0,0 +1,261 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.23.4
+// source: prover/v1/prover.proto
+
+package proverv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	ProverService_Generate_FullMethodName       = "/iden3.prover.v1.ProverService/Generate"
+	ProverService_Verify_FullMethodName         = "/iden3.prover.v1.ProverService/Verify"
+	ProverService_GenerateStream_FullMethodName = "/iden3.prover.v1.ProverService/GenerateStream"
+	ProverService_ListCircuits_FullMethodName   = "/iden3.prover.v1.ProverService/ListCircuits"
+)
+
+// ProverServiceClient is the client API for ProverService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ProverServiceClient interface {
+	// Generate generates proof for the circuit
+	Generate(ctx context.Context, in *GenerateRequest, opts ...grpc.CallOption) (*GenerateResponse, error)
+	// Verify verifies proof with verification key of the circuit
+	Verify(ctx context.Context, in *VerifyRequest, opts ...grpc.CallOption) (*VerifyResponse, error)
+	// GenerateStream generates proofs for a batch of requests, responses are sent as soon as proofs are ready
+	GenerateStream(ctx context.Context, opts ...grpc.CallOption) (ProverService_GenerateStreamClient, error)
+	// ListCircuits returns circuits available on the server
+	ListCircuits(ctx context.Context, in *ListCircuitsRequest, opts ...grpc.CallOption) (*ListCircuitsResponse, error)
+}
+
+type proverServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewProverServiceClient(cc grpc.ClientConnInterface) ProverServiceClient {
+	return &proverServiceClient{cc}
+}
+
+func (c *proverServiceClient) Generate(ctx context.Context, in *GenerateRequest, opts ...grpc.CallOption) (*GenerateResponse, error) {
+	out := new(GenerateResponse)
+	err := c.cc.Invoke(ctx, ProverService_Generate_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *proverServiceClient) Verify(ctx context.Context, in *VerifyRequest, opts ...grpc.CallOption) (*VerifyResponse, error) {
+	out := new(VerifyResponse)
+	err := c.cc.Invoke(ctx, ProverService_Verify_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *proverServiceClient) GenerateStream(ctx context.Context, opts ...grpc.CallOption) (ProverService_GenerateStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ProverService_ServiceDesc.Streams[0], ProverService_GenerateStream_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &proverServiceGenerateStreamClient{stream}
+	return x, nil
+}
+
+type ProverService_GenerateStreamClient interface {
+	Send(*GenerateRequest) error
+	Recv() (*GenerateStreamResponse, error)
+	grpc.ClientStream
+}
+
+type proverServiceGenerateStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *proverServiceGenerateStreamClient) Send(m *GenerateRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *proverServiceGenerateStreamClient) Recv() (*GenerateStreamResponse, error) {
+	m := new(GenerateStreamResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *proverServiceClient) ListCircuits(ctx context.Context, in *ListCircuitsRequest, opts ...grpc.CallOption) (*ListCircuitsResponse, error) {
+	out := new(ListCircuitsResponse)
+	err := c.cc.Invoke(ctx, ProverService_ListCircuits_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ProverServiceServer is the server API for ProverService service.
+// All implementations must embed UnimplementedProverServiceServer
+// for forward compatibility
+type ProverServiceServer interface {
+	// Generate generates proof for the circuit
+	Generate(context.Context, *GenerateRequest) (*GenerateResponse, error)
+	// Verify verifies proof with verification key of the circuit
+	Verify(context.Context, *VerifyRequest) (*VerifyResponse, error)
+	// GenerateStream generates proofs for a batch of requests, responses are sent as soon as proofs are ready
+	GenerateStream(ProverService_GenerateStreamServer) error
+	// ListCircuits returns circuits available on the server
+	ListCircuits(context.Context, *ListCircuitsRequest) (*ListCircuitsResponse, error)
+	mustEmbedUnimplementedProverServiceServer()
+}
+
+// UnimplementedProverServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedProverServiceServer struct {
+}
+
+func (UnimplementedProverServiceServer) Generate(context.Context, *GenerateRequest) (*GenerateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Generate not implemented")
+}
+func (UnimplementedProverServiceServer) Verify(context.Context, *VerifyRequest) (*VerifyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Verify not implemented")
+}
+func (UnimplementedProverServiceServer) GenerateStream(ProverService_GenerateStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method GenerateStream not implemented")
+}
+func (UnimplementedProverServiceServer) ListCircuits(context.Context, *ListCircuitsRequest) (*ListCircuitsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCircuits not implemented")
+}
+func (UnimplementedProverServiceServer) mustEmbedUnimplementedProverServiceServer() {}
+
+// UnsafeProverServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ProverServiceServer will
+// result in compilation errors.
+type UnsafeProverServiceServer interface {
+	mustEmbedUnimplementedProverServiceServer()
+}
+
+func RegisterProverServiceServer(s grpc.ServiceRegistrar, srv ProverServiceServer) {
+	s.RegisterService(&ProverService_ServiceDesc, srv)
+}
+
+func _ProverService_Generate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GenerateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProverServiceServer).Generate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProverService_Generate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProverServiceServer).Generate(ctx, req.(*GenerateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProverService_Verify_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProverServiceServer).Verify(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProverService_Verify_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProverServiceServer).Verify(ctx, req.(*VerifyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProverService_GenerateStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ProverServiceServer).GenerateStream(&proverServiceGenerateStreamServer{stream})
+}
+
+type ProverService_GenerateStreamServer interface {
+	Send(*GenerateStreamResponse) error
+	Recv() (*GenerateRequest, error)
+	grpc.ServerStream
+}
+
+type proverServiceGenerateStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *proverServiceGenerateStreamServer) Send(m *GenerateStreamResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *proverServiceGenerateStreamServer) Recv() (*GenerateRequest, error) {
+	m := new(GenerateRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _ProverService_ListCircuits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCircuitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProverServiceServer).ListCircuits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProverService_ListCircuits_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProverServiceServer).ListCircuits(ctx, req.(*ListCircuitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ProverService_ServiceDesc is the grpc.ServiceDesc for ProverService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ProverService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "iden3.prover.v1.ProverService",
+	HandlerType: (*ProverServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Generate",
+			Handler:    _ProverService_Generate_Handler,
+		},
+		{
+			MethodName: "Verify",
+			Handler:    _ProverService_Verify_Handler,
+		},
+		{
+			MethodName: "ListCircuits",
+			Handler:    _ProverService_ListCircuits_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GenerateStream",
+			Handler:       _ProverService_GenerateStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "prover/v1/prover.proto",
+}
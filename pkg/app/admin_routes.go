@@ -0,0 +1,58 @@
+package app
+
+import (
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+	"github.com/iden3/prover-server/pkg/app/handlers"
+	customMiddleware "github.com/iden3/prover-server/pkg/app/middleware"
+)
+
+// adminRealm is a realm of admin API basic authentication
+const adminRealm = "prover-admin"
+
+// AdminHandlers contain handlers of admin API, which is served on its own listeners
+type AdminHandlers struct {
+	AdminHandler *handlers.AdminHandler
+
+	// Username and Password are credentials of admin API basic authentication
+	Username string
+	Password string
+}
+
+// Routes initializes admin router
+func (s *AdminHandlers) Routes() chi.Router {
+
+	r := chi.NewRouter()
+
+	r.Use(middleware.RequestID)
+	r.Use(middleware.RealIP)
+	r.Use(customMiddleware.ZapContextLogger)
+	r.Use(middleware.Recoverer)
+	r.Use(middleware.BasicAuth(adminRealm, map[string]string{s.Username: s.Password}))
+	r.Route("/admin", func(admin chi.Router) {
+
+		admin.Use(render.SetContentType(render.ContentTypeJSON))
+
+		admin.Get("/status", s.AdminHandler.GetStatus)
+		admin.Put("/log/level", s.AdminHandler.SetLogLevel)
+		admin.Post("/selftest", s.AdminHandler.SelfTest)
+
+		admin.Route("/circuits", func(rr chi.Router) {
+			rr.Get("/", s.AdminHandler.GetCircuits)
+			rr.Post("/reload", s.AdminHandler.ReloadCircuits)
+			rr.Post("/{name}/disable", s.AdminHandler.DisableCircuit)
+			rr.Post("/{name}/enable", s.AdminHandler.EnableCircuit)
+			rr.Put("/{name}", s.AdminHandler.UploadCircuit)
+		})
+
+		admin.Route("/uploads", func(rr chi.Router) {
+			rr.Post("/", s.AdminHandler.CreateUpload)
+			rr.Get("/{id}", s.AdminHandler.GetUpload)
+			rr.Put("/{id}/{file}", s.AdminHandler.WriteUpload)
+			rr.Delete("/{id}", s.AdminHandler.DeleteUpload)
+		})
+	})
+
+	return r
+}
@@ -0,0 +1,141 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/iden3/prover-server/pkg/app/configs"
+	"github.com/iden3/prover-server/pkg/app/handlers"
+	"github.com/iden3/prover-server/pkg/circuits"
+	"github.com/iden3/prover-server/pkg/circuits/circuitstest"
+	"github.com/iden3/prover-server/pkg/log"
+	"github.com/iden3/prover-server/pkg/proof"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminRoutes(t *testing.T) {
+	circuitsPath := t.TempDir()
+	circuitstest.WriteCircuit(t, circuitsPath, "auth")
+	registry, err := circuits.NewRegistry(configs.ProverConfig{CircuitsBasePath: circuitsPath})
+	require.NoError(t, err)
+	defer registry.Close()
+
+	zk := handlers.NewZKHandler(registry, nil)
+	apiHandlers := Handlers{ZKHandler: zk}
+	api := httptest.NewServer(apiHandlers.Routes())
+	defer api.Close()
+
+	adminHandlers := AdminHandlers{
+		AdminHandler: handlers.NewAdminHandler(zk, nil),
+		Username:     "admin",
+		Password:     "secret",
+	}
+	admin := httptest.NewServer(adminHandlers.Routes())
+	defer admin.Close()
+
+	do := func(method, path, password string, body interface{}) *http.Response {
+		var b bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&b).Encode(body))
+		}
+		req, err := http.NewRequest(method, admin.URL+path, &b)
+		require.NoError(t, err)
+		req.SetBasicAuth("admin", password)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	require.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/admin/status", "wrong", nil).StatusCode)
+
+	// disabled circuit is rejected by API
+	resp := do(http.MethodPost, "/admin/circuits/auth/disable", "secret", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, http.StatusNotFound, do(http.MethodPost, "/admin/circuits/unknown/disable", "secret", nil).StatusCode)
+
+	apiResp, err := http.Post(api.URL+"/api/v1/proof/generate", "application/json",
+		bytes.NewBufferString(`{"circuit_name":"auth","inputs":{}}`))
+	require.NoError(t, err)
+	apiResp.Body.Close()
+	require.Equal(t, http.StatusServiceUnavailable, apiResp.StatusCode)
+
+	var status handlers.AdminStatusResp
+	resp = do(http.MethodGet, "/admin/status", "secret", nil)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+	require.Len(t, status.Circuits, 1)
+	require.Equal(t, "auth", status.Circuits[0].Name)
+	require.True(t, status.Circuits[0].Disabled)
+
+	// new circuit is available after reload
+	circuitstest.WriteCircuit(t, circuitsPath, "stateTransition")
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "/admin/circuits/reload", "secret", nil).StatusCode)
+	_, err = registry.Get("stateTransition")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "/admin/selftest", "secret", nil).StatusCode)
+
+	// self-test fails for circuit that can't be loaded
+	require.NoError(t, os.Mkdir(filepath.Join(circuitsPath, "broken"), 0o755))
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "/admin/circuits/reload", "secret", nil).StatusCode)
+	require.Equal(t, http.StatusServiceUnavailable, do(http.MethodPost, "/admin/selftest", "secret", nil).StatusCode)
+
+	// zkey is uploaded by chunks, circuit which can't generate test proof isn't installed
+	var upload circuits.Upload
+	resp = do(http.MethodPost, "/admin/uploads", "secret", nil)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&upload))
+	zkey := circuitstest.Artifacts[proof.ZKeyFile]
+	uploadChunk := func(file string, start int, chunk []byte) int {
+		req, err := http.NewRequest(http.MethodPut, admin.URL+"/admin/uploads/"+upload.ID+"/"+file,
+			bytes.NewReader(chunk))
+		require.NoError(t, err)
+		req.SetBasicAuth("admin", "secret")
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/*", start, start+len(chunk)-1))
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	require.Equal(t, http.StatusOK, uploadChunk(circuits.UploadZKey, 0, zkey[:4]))
+	require.Equal(t, http.StatusConflict, uploadChunk(circuits.UploadZKey, 6, zkey[6:]))
+	require.Equal(t, http.StatusOK, uploadChunk(circuits.UploadZKey, 4, zkey[4:]))
+	require.Equal(t, http.StatusOK, uploadChunk(circuits.UploadWasm, 0, circuitstest.Artifacts[proof.WasmFile]))
+	require.Equal(t, http.StatusOK, uploadChunk(circuits.UploadInputs, 0, []byte("{}")))
+	resp = do(http.MethodGet, "/admin/uploads/"+upload.ID, "secret", nil)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&upload))
+	require.EqualValues(t, len(zkey), upload.Files[circuits.UploadZKey])
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile(circuits.UploadVerificationKey, proof.VerificationKeyFile)
+	require.NoError(t, err)
+	_, err = part.Write(circuitstest.Artifacts[proof.VerificationKeyFile])
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+	req, err := http.NewRequest(http.MethodPut, admin.URL+"/admin/circuits/uploaded?upload_id="+upload.ID, &body)
+	require.NoError(t, err)
+	req.SetBasicAuth("admin", "secret")
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	uploadResp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	uploadResp.Body.Close()
+	require.Equal(t, http.StatusUnprocessableEntity, uploadResp.StatusCode)
+	_, err = registry.Get("uploaded")
+	require.ErrorIs(t, err, circuits.ErrNotFound)
+	require.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/admin/uploads/"+upload.ID, "secret", nil).StatusCode)
+	require.Equal(t, http.StatusNotFound, do(http.MethodGet, "/admin/uploads/"+upload.ID, "secret", nil).StatusCode)
+
+	defer log.SetLevelStr(log.GetLevelStr())
+	require.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/admin/log/level", "secret",
+		handlers.LogLevelReq{Level: "verbose"}).StatusCode)
+	require.Equal(t, http.StatusOK, do(http.MethodPut, "/admin/log/level", "secret",
+		handlers.LogLevelReq{Level: "warn"}).StatusCode)
+	require.Equal(t, "warn", log.GetLevelStr())
+}
@@ -2,6 +2,7 @@ package configs
 
 import (
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
@@ -12,16 +13,283 @@ type Config struct {
 	Server struct {
 		Port int    `mapstructure:"port"`
 		Host string `mapstructure:"host"`
+		// Socket is a path of unix domain socket the API is served on in addition to host and port
+		Socket string `mapstructure:"socket"`
+		// Listen is a list of additional addresses, tcp "host:port" or "unix:/path/to/socket"
+		Listen []string  `mapstructure:"listen"`
+		TLS    TLSConfig `mapstructure:"tls"`
 	} `mapstructure:"server"`
-	Prover ProverConfig `mapstructure:"prover"`
-	Log    struct {
+	GRPC        GRPCConfig        `mapstructure:"grpc"`
+	Admin       AdminConfig       `mapstructure:"admin"`
+	Prover      ProverConfig      `mapstructure:"prover"`
+	Idempotency IdempotencyConfig `mapstructure:"idempotency"`
+	Jobs        JobsConfig        `mapstructure:"jobs"`
+	Cluster     ClusterConfig     `mapstructure:"cluster"`
+	Log         struct {
 		Level string `json:"level"`
 	}
 }
 
-// ProverConfig contains only base path to circuits folder
+// TLSConfig contains settings of TLS and mutual TLS, TLS is disabled if CertFile is empty
+type TLSConfig struct {
+	CertFile string `mapstructure:"certFile"`
+	KeyFile  string `mapstructure:"keyFile"`
+	// ClientCAFile is a CA bundle client certificates are verified against
+	ClientCAFile string `mapstructure:"clientCAFile"`
+	// ClientAuth is one of: none, request (verify client certificate if it's provided), require
+	ClientAuth string `mapstructure:"clientAuth"`
+	// AllowedClientCNs restricts API to clients with these certificate common names
+	AllowedClientCNs []string `mapstructure:"allowedClientCNs"`
+	// ReloadInterval is how often certificate files are checked for changes
+	ReloadInterval time.Duration `mapstructure:"reloadInterval"`
+}
+
+// GRPCConfig contains settings of gRPC API
+type GRPCConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Port is a separate port for gRPC API on the server host, gRPC is served on the server listeners if it's 0
+	Port int `mapstructure:"port"`
+}
+
+// AdminConfig contains settings of admin API, it's disabled if Listen is empty
+type AdminConfig struct {
+	// Listen is a list of addresses of admin API, tcp "host:port" or "unix:/path/to/socket"
+	Listen   []string `mapstructure:"listen"`
+	Username string   `mapstructure:"username"`
+	Password string   `mapstructure:"password"`
+}
+
+// ProverConfig contains base path to circuits folder or archive and settings of circuits loading
 type ProverConfig struct {
 	CircuitsBasePath string `mapstructure:"circuitsBasePath"`
+	// SnapshotPath is a directory artifact files of loaded circuits are copied to, temporary directory is used
+	// if it's empty
+	SnapshotPath string `mapstructure:"snapshotPath"`
+	// WatchCircuits enables reload of circuits when files in circuits folder change
+	WatchCircuits bool `mapstructure:"watchCircuits"`
+	// WatchDebounce is a period without changes after which circuits are reloaded
+	WatchDebounce time.Duration `mapstructure:"watchDebounce"`
+	// Aliases are alternative names of circuits
+	Aliases []AliasConfig `mapstructure:"aliases"`
+	// PinnedHashes are hashes circuit artifacts must match, in addition to hashes from circuit manifest
+	PinnedHashes []PinnedHashesConfig `mapstructure:"pinnedHashes"`
+	// Layout is a template of artifact paths of all circuits
+	Layout LayoutConfig `mapstructure:"layout"`
+	// Layouts override Layout for particular circuits, they are overridden by files from circuit manifest
+	Layouts []CircuitLayoutConfig `mapstructure:"layouts"`
+	Remote  RemoteConfig          `mapstructure:"remote"`
+	// Backend is a prover backend of all circuits
+	Backend ProverBackendConfig `mapstructure:"backend"`
+	// Backends override Backend for particular circuits
+	Backends []CircuitBackendConfig `mapstructure:"backends"`
+	// Witness is a witness calculator of all circuits
+	Witness WitnessConfig `mapstructure:"witness"`
+	// Witnesses override Witness for particular circuits
+	Witnesses []CircuitWitnessConfig `mapstructure:"witnesses"`
+	// Workers are subprocesses proofs are generated in
+	Workers WorkersConfig `mapstructure:"workers"`
+	// Timeout limits duration of proof generation of all circuits, 0 disables it
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Timeouts override Timeout for particular circuits
+	Timeouts []CircuitTimeoutConfig `mapstructure:"timeouts"`
+	Memory   MemoryConfig           `mapstructure:"memory"`
+	Priority PriorityConfig         `mapstructure:"priority"`
+}
+
+// PriorityConfig contains settings of priority lanes (high, normal and low) proofs wait in when they are
+// queued by memory budget or workers. Priority is requested by X-Priority header, it can't exceed priority of
+// the client, requests without it get priority of the client or default priority of the circuit.
+type PriorityConfig struct {
+	// Default is a priority of circuits, normal if it's empty
+	Default string `mapstructure:"default"`
+	// MaxWait is a time after which request of lower lane is served ahead of higher lanes, 0 disables it
+	MaxWait time.Duration `mapstructure:"maxWait"`
+	// Circuits override Default for particular circuits
+	Circuits []CircuitPriorityConfig `mapstructure:"circuits"`
+	// Clients are priorities of clients identified by client certificate common name or X-API-Key header
+	Clients []ClientPriorityConfig `mapstructure:"clients"`
+}
+
+// CircuitPriorityConfig is a default priority of the circuit referenced by name@version or directory name
+type CircuitPriorityConfig struct {
+	Circuit  string `mapstructure:"circuit"`
+	Priority string `mapstructure:"priority"`
+}
+
+// ClientPriorityConfig is a priority of the client with certificate common name ClientCN or API key APIKey
+type ClientPriorityConfig struct {
+	ClientCN string `mapstructure:"clientCN"`
+	APIKey   string `mapstructure:"apiKey"`
+	Priority string `mapstructure:"priority"`
+}
+
+// MemoryConfig contains settings of memory-aware scheduling: proofs are generated in parallel while sum of memory
+// estimates of their circuits stays within BudgetMB, other requests are queued. It's disabled if BudgetMB is 0.
+type MemoryConfig struct {
+	BudgetMB int64 `mapstructure:"budgetMB"`
+	// ZKeyFactor is a ratio of peak memory of proof generation to zkey size used for circuits without
+	// measured memory in config or manifest
+	ZKeyFactor float64 `mapstructure:"zkeyFactor"`
+	// Circuits are measured peak memory of particular circuits
+	Circuits []CircuitMemoryConfig `mapstructure:"circuits"`
+	// ArtifactCacheMB limits size of artifacts of recently used circuits kept in memory (split equally between
+	// worker processes when workers are enabled), 0 reads artifacts from circuit files for every proof
+	ArtifactCacheMB int64 `mapstructure:"artifactCacheMB"`
+}
+
+// CircuitMemoryConfig is a peak memory of proof generation of the circuit referenced by name@version or directory name
+type CircuitMemoryConfig struct {
+	Circuit  string `mapstructure:"circuit"`
+	MemoryMB int64  `mapstructure:"memoryMB"`
+}
+
+// CircuitTimeoutConfig is a timeout of proof generation of the circuit referenced by name@version or directory name
+type CircuitTimeoutConfig struct {
+	Circuit string        `mapstructure:"circuit"`
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// WorkersConfig contains settings of worker subprocesses, which isolate crashes of witness calculation and
+// proof generation from the server. Proofs are generated in the server process if Count is 0.
+type WorkersConfig struct {
+	// Count is a number of worker processes, it limits number of proofs generated in parallel
+	Count int `mapstructure:"count"`
+}
+
+// ProverBackendConfig selects implementation of proof generation
+type ProverBackendConfig struct {
+	// Type is rapidsnark (native library, default, gnark in builds with norapidsnark tag), binary (rapidsnark
+	// executable) or gnark (pure Go)
+	Type string `mapstructure:"type"`
+	// Path is a path of executable of binary backend
+	Path string `mapstructure:"path"`
+}
+
+// CircuitBackendConfig is a prover backend of the circuit referenced by name@version or directory name
+type CircuitBackendConfig struct {
+	Circuit             string `mapstructure:"circuit"`
+	ProverBackendConfig `mapstructure:",squash"`
+}
+
+// WitnessConfig selects engine of witness calculation
+type WitnessConfig struct {
+	// Type is wasmer (default), wazero (pure Go wasm runtime) or binary (native witness generator of circuit)
+	Type string `mapstructure:"type"`
+	// Path is a path of executable of binary engine, "{name}" is replaced with circuit name and "{dir}" with
+	// circuit directory name
+	Path string `mapstructure:"path"`
+}
+
+// CircuitWitnessConfig is a witness calculator of the circuit referenced by name@version or directory name
+type CircuitWitnessConfig struct {
+	Circuit       string `mapstructure:"circuit"`
+	WitnessConfig `mapstructure:",squash"`
+}
+
+// RemoteConfig contains settings of remote store circuits are fetched from on first request,
+// it's disabled if Type is empty
+type RemoteConfig struct {
+	// Type is http or s3
+	Type string `mapstructure:"type"`
+	// URL is a base URL of circuit directories for http store, or endpoint of S3-compatible store
+	URL       string `mapstructure:"url"`
+	Bucket    string `mapstructure:"bucket"`
+	Prefix    string `mapstructure:"prefix"`
+	Region    string `mapstructure:"region"`
+	AccessKey string `mapstructure:"accessKey"`
+	SecretKey string `mapstructure:"secretKey"`
+	// Circuits are names of circuit directories in the store
+	Circuits []string `mapstructure:"circuits"`
+	// CachePath is a directory fetched circuits are kept in, it's loaded like circuits directory
+	CachePath string `mapstructure:"cachePath"`
+	// Prefetch enables fetching of all circuits on start instead of the first request
+	Prefetch bool `mapstructure:"prefetch"`
+}
+
+// LayoutConfig contains templates of artifact paths relative to circuit directory, "{name}" is replaced with
+// circuit name and "{dir}" with circuit directory name. Default paths are used for empty templates.
+type LayoutConfig struct {
+	Wasm            string `mapstructure:"wasm"`
+	ZKey            string `mapstructure:"zkey"`
+	VerificationKey string `mapstructure:"verificationKey"`
+}
+
+// CircuitLayoutConfig is a layout of the circuit referenced by name@version or directory name
+type CircuitLayoutConfig struct {
+	Circuit      string `mapstructure:"circuit"`
+	LayoutConfig `mapstructure:",squash"`
+}
+
+// AliasConfig maps alias to circuit reference, e.g. "auth@latest" to "auth@2.0.0"
+type AliasConfig struct {
+	Alias   string `mapstructure:"alias"`
+	Circuit string `mapstructure:"circuit"`
+}
+
+// PinnedHashesConfig contains hashes of artifacts of the circuit referenced by name@version or directory name.
+// Hash is hex encoded "sha256:<hash>" or "blake2b:<hash>", empty hash isn't checked.
+type PinnedHashesConfig struct {
+	Circuit         string `mapstructure:"circuit"`
+	Wasm            string `mapstructure:"wasm"`
+	ZKey            string `mapstructure:"zkey"`
+	VerificationKey string `mapstructure:"verificationKey"`
+}
+
+// IdempotencyConfig contains settings of idempotency keys support, zero TTL disables it
+type IdempotencyConfig struct {
+	TTL time.Duration `mapstructure:"ttl"`
+}
+
+// JobsConfig contains settings of background proof generation jobs
+type JobsConfig struct {
+	// StorePath is a path to BoltDB file with jobs, jobs are kept in memory only if it's empty
+	StorePath string         `mapstructure:"storePath"`
+	Workers   int            `mapstructure:"workers"`
+	Retention time.Duration  `mapstructure:"retention"`
+	Webhooks  WebhooksConfig `mapstructure:"webhooks"`
+}
+
+// WebhooksConfig contains settings of job result delivery to callback URLs
+type WebhooksConfig struct {
+	// Secret is a key for HMAC-SHA256 signature of callback body
+	Secret         string        `mapstructure:"secret"`
+	MaxAttempts    int           `mapstructure:"maxAttempts"`
+	InitialBackoff time.Duration `mapstructure:"initialBackoff"`
+	MaxBackoff     time.Duration `mapstructure:"maxBackoff"`
+	Timeout        time.Duration `mapstructure:"timeout"`
+	// AllowPrivateNetworks allows callbacks to loopback, private and link-local addresses
+	AllowPrivateNetworks bool `mapstructure:"allowPrivateNetworks"`
+}
+
+// ClusterConfig contains settings of distributed proving: server in coordinator mode dispatches proofs to worker
+// instances, which register with the coordinator by heartbeats
+type ClusterConfig struct {
+	// Coordinator enables coordinator mode, proofs are generated by registered workers instead of the server
+	Coordinator bool `mapstructure:"coordinator"`
+	// CoordinatorURL is a base URL of API of the coordinator the server registers with as worker
+	CoordinatorURL string `mapstructure:"coordinatorURL"`
+	// AdvertiseURL is a base URL of API of the worker the coordinator sends requests to
+	AdvertiseURL string `mapstructure:"advertiseURL"`
+	// ID identifies the worker, AdvertiseURL is used if it's empty
+	ID string `mapstructure:"id"`
+	// Capacity is a number of proofs the worker generates in parallel, coordinator prefers less loaded workers
+	// and doesn't send more proofs to the worker
+	Capacity int `mapstructure:"capacity"`
+	// Token authenticates heartbeats of workers, it's sent as bearer token
+	Token string `mapstructure:"token"`
+	// ClientCertFile and ClientKeyFile are a client certificate the coordinator presents to workers with mutual TLS
+	ClientCertFile string `mapstructure:"clientCertFile"`
+	ClientKeyFile  string `mapstructure:"clientKeyFile"`
+	// CAFile is a CA bundle certificates of workers are verified against, system roots are used if it's empty
+	CAFile string `mapstructure:"caFile"`
+	// APIKey is sent by the coordinator to workers in X-API-Key header, so workers can give it priority of a client
+	APIKey string `mapstructure:"apiKey"`
+	// HeartbeatInterval is how often workers send heartbeats
+	HeartbeatInterval time.Duration `mapstructure:"heartbeatInterval"`
+	// HeartbeatTimeout is a time after the last heartbeat the worker is removed
+	HeartbeatTimeout time.Duration `mapstructure:"heartbeatTimeout"`
+	// Retries is a number of other workers request is sent to when worker fails
+	Retries int `mapstructure:"retries"`
 }
 
 // ReadConfigFromFile parse config file
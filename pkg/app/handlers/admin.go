@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/iden3/prover-server/pkg/app/rest"
+	"github.com/iden3/prover-server/pkg/circuits"
+	"github.com/iden3/prover-server/pkg/cluster"
+	"github.com/iden3/prover-server/pkg/jobs"
+	"github.com/iden3/prover-server/pkg/log"
+	"github.com/iden3/prover-server/pkg/scheduler"
+	"github.com/pkg/errors"
+)
+
+// SelfTestInputsFile is a file in circuit directory with inputs used to generate proof during self-test
+const SelfTestInputsFile = circuits.SelfTestInputsFile
+
+// AdminHandler is handler for operational controls of the server
+type AdminHandler struct {
+	zk   *ZKHandler
+	jobs *jobs.Manager
+}
+
+// LogLevelReq is request for change of log level
+type LogLevelReq struct {
+	Level string `json:"level"`
+}
+
+// LogLevelResp is response with current log level
+type LogLevelResp struct {
+	Level string `json:"level"`
+}
+
+// AdminStatusResp is response with state of the server
+type AdminStatusResp struct {
+	LogLevel string `json:"log_level"`
+	// InFlight is a number of proofs being generated for synchronous requests
+	InFlight int `json:"in_flight"`
+	// Queue is usage of budget of proof generation and depth of its lanes
+	Queue *scheduler.Stats `json:"queue,omitempty"`
+	Jobs  *jobs.Stats      `json:"jobs,omitempty"`
+	// Cluster are workers registered with coordinator
+	Cluster  []cluster.WorkerStatus `json:"cluster,omitempty"`
+	Circuits []circuits.Circuit     `json:"circuits"`
+	// CircuitErrors are errors of circuit directories that failed to load
+	CircuitErrors map[string]string `json:"circuit_errors,omitempty"`
+}
+
+// AdminCircuitsResp is response with all circuits including disabled ones
+type AdminCircuitsResp struct {
+	Circuits []circuits.Circuit `json:"circuits"`
+	Aliases  map[string]string  `json:"aliases,omitempty"`
+	// Errors are errors of circuit directories that failed to load
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// CircuitStateResp is response for change of circuit state
+type CircuitStateResp struct {
+	// Circuit is name@version of the circuit, or name if the circuit has no version
+	Circuit  string `json:"circuit"`
+	Disabled bool   `json:"disabled"`
+}
+
+// SelfTestResult is result of self-test of a single circuit
+type SelfTestResult struct {
+	Circuit string `json:"circuit"`
+	Passed  bool   `json:"passed"`
+	// ProofGenerated reports whether test proof was generated, it requires inputs in SelfTestInputsFile
+	ProofGenerated bool   `json:"proof_generated"`
+	DurationMs     int64  `json:"duration_ms"`
+	Error          string `json:"error,omitempty"`
+}
+
+// SelfTestResp is response for self-test
+type SelfTestResp struct {
+	Passed  bool             `json:"passed"`
+	Results []SelfTestResult `json:"results"`
+}
+
+// NewAdminHandler creates new instance of handler, manager is optional
+func NewAdminHandler(zk *ZKHandler, manager *jobs.Manager) *AdminHandler {
+	return &AdminHandler{
+		zk:   zk,
+		jobs: manager,
+	}
+}
+
+// GetStatus is a handler returning log level, queue and worker state and circuits
+// GET /admin/status
+func (h *AdminHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+
+	resp := AdminStatusResp{
+		LogLevel: log.GetLevelStr(),
+		InFlight: h.zk.InFlight(),
+		Queue:    h.zk.QueueStats(),
+		Cluster:  h.zk.ClusterWorkers(),
+		Circuits: h.zk.Circuits.List(),
+
+		CircuitErrors: h.zk.Circuits.LoadErrors(),
+	}
+	if h.jobs != nil {
+		stats := h.jobs.Stats()
+		resp.Jobs = &stats
+	}
+	render.JSON(w, r, resp)
+}
+
+// SetLogLevel is a handler changing log level
+// PUT /admin/log/level
+func (h *AdminHandler) SetLogLevel(w http.ResponseWriter, r *http.Request) {
+
+	var req LogLevelReq
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		rest.ErrorJSON(w, r, http.StatusBadRequest, err, "can't bind request", 0)
+		return
+	}
+
+	if err := log.SetLevelStr(req.Level); err != nil {
+		rest.ErrorJSON(w, r, http.StatusBadRequest, err, "invalid log level", 0)
+		return
+	}
+	log.WithContext(r.Context()).Infow("Log level changed", "level", req.Level)
+
+	render.JSON(w, r, LogLevelResp{Level: log.GetLevelStr()})
+}
+
+// GetCircuits is a handler returning all circuits including disabled ones
+// GET /admin/circuits
+func (h *AdminHandler) GetCircuits(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, AdminCircuitsResp{
+		Circuits: h.zk.Circuits.List(),
+		Aliases:  h.zk.Circuits.Aliases(),
+		Errors:   h.zk.Circuits.LoadErrors(),
+	})
+}
+
+// ReloadCircuits is a handler rescanning circuits directory
+// POST /admin/circuits/reload
+func (h *AdminHandler) ReloadCircuits(w http.ResponseWriter, r *http.Request) {
+
+	if err := h.zk.Circuits.Reload(); err != nil {
+		rest.ErrorJSON(w, r, http.StatusInternalServerError, err, "can't reload circuits", 0)
+		return
+	}
+	log.WithContext(r.Context()).Infow("Circuits reloaded")
+
+	render.JSON(w, r, AdminCircuitsResp{
+		Circuits: h.zk.Circuits.List(),
+		Aliases:  h.zk.Circuits.Aliases(),
+		Errors:   h.zk.Circuits.LoadErrors(),
+	})
+}
+
+// DisableCircuit is a handler disabling circuit, requests for it are rejected until it's enabled
+// POST /admin/circuits/{name}/disable
+func (h *AdminHandler) DisableCircuit(w http.ResponseWriter, r *http.Request) {
+	h.setCircuitDisabled(w, r, true)
+}
+
+// EnableCircuit is a handler enabling previously disabled circuit
+// POST /admin/circuits/{name}/enable
+func (h *AdminHandler) EnableCircuit(w http.ResponseWriter, r *http.Request) {
+	h.setCircuitDisabled(w, r, false)
+}
+
+func (h *AdminHandler) setCircuitDisabled(w http.ResponseWriter, r *http.Request, disabled bool) {
+
+	ref := chi.URLParam(r, "name")
+	id, err := h.zk.Circuits.SetDisabled(ref, disabled)
+	if errors.Is(err, circuits.ErrNotFound) {
+		rest.ErrorJSON(w, r, http.StatusNotFound, err, "unknown circuit", 0)
+		return
+	}
+	if err != nil {
+		rest.ErrorJSON(w, r, http.StatusBadRequest, err, "illegal circuitPath", 0)
+		return
+	}
+	log.WithContext(r.Context()).Infow("Circuit state changed", "circuit", id, "disabled", disabled)
+
+	render.JSON(w, r, CircuitStateResp{Circuit: id, Disabled: disabled})
+}
+
+// SelfTest is a handler checking that all circuits are loaded and generating test proofs for enabled circuits
+// with SelfTestInputsFile, only the circuit from "circuit" query parameter is tested if it's set
+// POST /admin/selftest
+func (h *AdminHandler) SelfTest(w http.ResponseWriter, r *http.Request) {
+
+	var tested []circuits.Circuit
+	if name := r.URL.Query().Get("circuit"); name != "" {
+		c, err := h.zk.Circuits.Resolve(r.Context(), name)
+		if err != nil {
+			circuitErrorJSON(w, r, err)
+			return
+		}
+		tested = append(tested, c)
+	} else {
+		for _, c := range h.zk.Circuits.List() {
+			if !c.Disabled {
+				tested = append(tested, c)
+			}
+		}
+	}
+
+	resp := SelfTestResp{Passed: true, Results: make([]SelfTestResult, 0, len(tested))}
+	for _, c := range tested {
+		res := h.selfTest(r, c)
+		resp.Passed = resp.Passed && res.Passed
+		resp.Results = append(resp.Results, res)
+	}
+	if r.URL.Query().Get("circuit") == "" {
+		loadErrors := h.zk.Circuits.LoadErrors()
+		names := make([]string, 0, len(loadErrors))
+		for name := range loadErrors {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			resp.Passed = false
+			resp.Results = append(resp.Results, SelfTestResult{Circuit: name, Error: loadErrors[name]})
+		}
+	}
+	log.WithContext(r.Context()).Infow("Self-test completed", "passed", resp.Passed)
+
+	if !resp.Passed {
+		render.Status(r, http.StatusServiceUnavailable)
+	}
+	render.JSON(w, r, resp)
+}
+
+func (h *AdminHandler) selfTest(r *http.Request, c circuits.Circuit) (res SelfTestResult) {
+	start := time.Now()
+	res.Circuit = c.ID()
+	defer func() { res.DurationMs = time.Since(start).Milliseconds() }()
+
+	inputs, err := readSelfTestInputs(c)
+	if os.IsNotExist(errors.Cause(err)) {
+		res.Passed = true
+		return res
+	}
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	// generated proof is verified before it's returned
+	if _, err = h.zk.Generate(r.Context(), c.ID(), inputs); err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	res.Passed = true
+	res.ProofGenerated = true
+	return res
+}
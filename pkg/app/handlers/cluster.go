@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/render"
+	"github.com/iden3/prover-server/pkg/app/rest"
+	"github.com/iden3/prover-server/pkg/cluster"
+	"github.com/pkg/errors"
+)
+
+// ClusterHandler is handler of coordinator API for workers of the cluster
+type ClusterHandler struct {
+	coordinator *cluster.Coordinator
+	token       string
+}
+
+// NewClusterHandler creates new instance of handler, heartbeats must carry token as bearer token. Heartbeats
+// are rejected if token is empty, since worker URL they register receives private inputs of proofs.
+func NewClusterHandler(coordinator *cluster.Coordinator, token string) *ClusterHandler {
+	return &ClusterHandler{
+		coordinator: coordinator,
+		token:       token,
+	}
+}
+
+// Heartbeat is a handler registering worker and refreshing its circuits
+// POST /api/v1/cluster/heartbeat
+func (h *ClusterHandler) Heartbeat(w http.ResponseWriter, r *http.Request) {
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if h.token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+		rest.ErrorJSON(w, r, http.StatusUnauthorized, errors.New("illegal cluster token"), "unauthorized", 0)
+		return
+	}
+
+	var req cluster.Heartbeat
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		rest.ErrorJSON(w, r, http.StatusBadRequest, err, "can't bind request", 0)
+		return
+	}
+	if err := h.coordinator.Heartbeat(req); err != nil {
+		rest.ErrorJSON(w, r, http.StatusBadRequest, err, "illegal heartbeat", 0)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
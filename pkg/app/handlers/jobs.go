@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/iden3/prover-server/pkg/app/rest"
+	"github.com/iden3/prover-server/pkg/circuits"
+	"github.com/iden3/prover-server/pkg/jobs"
+	"github.com/iden3/prover-server/pkg/log"
+	"github.com/iden3/prover-server/pkg/proof"
+	"github.com/iden3/prover-server/pkg/scheduler"
+	"github.com/pkg/errors"
+)
+
+// sseKeepAliveInterval is how often comments are sent to idle event streams to keep connections open
+const sseKeepAliveInterval = 15 * time.Second
+
+// JobsHandler is handler for background proof generation jobs
+type JobsHandler struct {
+	Circuits *circuits.Registry
+	jobs     *jobs.Manager
+}
+
+// SubmitJobReq is request for background proof generation
+type SubmitJobReq struct {
+	CircuitName string         `json:"circuit_name"`
+	Inputs      proof.ZKInputs `json:"inputs"`
+	CallbackURL string         `json:"callback_url,omitempty"`
+}
+
+// NewJobsHandler creates new instance of handler
+func NewJobsHandler(registry *circuits.Registry, manager *jobs.Manager) *JobsHandler {
+	return &JobsHandler{
+		Circuits: registry,
+		jobs:     manager,
+	}
+}
+
+// SubmitJob is a handler for submission of background proof generation job
+// POST /api/v1/proof/jobs
+func (h *JobsHandler) SubmitJob(w http.ResponseWriter, r *http.Request) {
+
+	var req SubmitJobReq
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		rest.ErrorJSON(w, r, http.StatusBadRequest, err, "can't bind request", 0)
+		return
+	}
+	log.WithContext(r.Context()).Debugw("Proof job submission", "inputs", req)
+
+	if _, err := h.Circuits.Resolve(r.Context(), req.CircuitName); err != nil {
+		circuitErrorJSON(w, r, err)
+		return
+	}
+
+	if req.CallbackURL != "" {
+		if err := h.jobs.ValidateCallbackURL(r.Context(), req.CallbackURL); err != nil {
+			rest.ErrorJSON(w, r, http.StatusBadRequest, err, "illegal callback_url", 0)
+			return
+		}
+	}
+
+	priority, _ := scheduler.PriorityFromContext(r.Context())
+	job, err := h.jobs.Submit(req.CircuitName, req.Inputs, req.CallbackURL, priority)
+	if err != nil {
+		rest.ErrorJSON(w, r, http.StatusInternalServerError, err, "can't submit job", 0)
+		return
+	}
+
+	w.Header().Set("Location", r.URL.Path+"/"+job.ID)
+	render.Status(r, http.StatusAccepted)
+	render.JSON(w, r, job.WithoutInputs())
+}
+
+// GetJob is a handler for job status and result
+// GET /api/v1/proof/jobs/{id}
+func (h *JobsHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+
+	job, err := h.jobs.Get(chi.URLParam(r, "id"))
+	if errors.Is(err, jobs.ErrNotFound) {
+		rest.ErrorJSON(w, r, http.StatusNotFound, err, "unknown job", 0)
+		return
+	}
+	if err != nil {
+		rest.ErrorJSON(w, r, http.StatusInternalServerError, err, "can't get job", 0)
+		return
+	}
+
+	render.JSON(w, r, job.WithoutInputs())
+}
+
+// JobEvents is a handler streaming job status and phase changes and the final result as server-sent events
+// GET /api/v1/proof/jobs/{id}/events
+func (h *JobsHandler) JobEvents(w http.ResponseWriter, r *http.Request) {
+
+	id := chi.URLParam(r, "id")
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		rest.ErrorJSON(w, r, http.StatusInternalServerError, errors.New("streaming unsupported"), "can't stream events", 0)
+		return
+	}
+
+	// subscribe before reading current state, so no change is missed in between
+	updates, unsubscribe := h.jobs.Subscribe(id)
+	defer unsubscribe()
+
+	job, err := h.jobs.Get(id)
+	if errors.Is(err, jobs.ErrNotFound) {
+		rest.ErrorJSON(w, r, http.StatusNotFound, err, "unknown job", 0)
+		return
+	}
+	if err != nil {
+		rest.ErrorJSON(w, r, http.StatusInternalServerError, err, "can't get job", 0)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if err = writeJobEvent(w, job.WithoutInputs()); err != nil || job.Status.Completed() {
+		flusher.Flush()
+		return
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err = fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case update, open := <-updates:
+			if !open {
+				// the job is completed, but the final update could be dropped for slow stream
+				if update, err = h.jobs.Get(id); err != nil || !update.Status.Completed() {
+					return
+				}
+			}
+			if err = writeJobEvent(w, update.WithoutInputs()); err != nil {
+				return
+			}
+			flusher.Flush()
+			if update.Status.Completed() {
+				return
+			}
+		}
+	}
+}
+
+// writeJobEvent writes job as server-sent event: "result" for completed job, "phase" while proof is
+// being generated and "status" otherwise
+func writeJobEvent(w http.ResponseWriter, job *jobs.Job) error {
+	event := "status"
+	switch {
+	case job.Status.Completed():
+		event = "result"
+	case job.Phase != "":
+		event = "phase"
+	}
+
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/iden3/prover-server/pkg/app/rest"
+	"github.com/iden3/prover-server/pkg/circuits"
+	"github.com/iden3/prover-server/pkg/log"
+	"github.com/iden3/prover-server/pkg/proof"
+	"github.com/pkg/errors"
+)
+
+// CreateUpload is a handler starting upload of circuit files by chunks
+// POST /admin/uploads
+func (h *AdminHandler) CreateUpload(w http.ResponseWriter, r *http.Request) {
+
+	uploads := h.zk.Circuits.Uploads()
+	if uploads == nil {
+		uploadErrorJSON(w, r, circuits.ErrUploadsDisabled)
+		return
+	}
+	upload, err := uploads.Create()
+	if err != nil {
+		uploadErrorJSON(w, r, err)
+		return
+	}
+	log.WithContext(r.Context()).Infow("Upload created", "upload", upload.ID)
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, upload)
+}
+
+// GetUpload is a handler returning sizes of uploaded files, upload of a file is resumed from its size
+// GET /admin/uploads/{id}
+func (h *AdminHandler) GetUpload(w http.ResponseWriter, r *http.Request) {
+
+	uploads := h.zk.Circuits.Uploads()
+	if uploads == nil {
+		uploadErrorJSON(w, r, circuits.ErrUploadsDisabled)
+		return
+	}
+	upload, err := uploads.Get(chi.URLParam(r, "id"))
+	if err != nil {
+		uploadErrorJSON(w, r, err)
+		return
+	}
+	render.JSON(w, r, upload)
+}
+
+// WriteUpload is a handler writing request body as a chunk of the file of upload. Offset of the chunk is taken
+// from Content-Range header ("bytes <start>-<end>/<total>"), chunk without it replaces the file.
+// PUT /admin/uploads/{id}/{file}
+func (h *AdminHandler) WriteUpload(w http.ResponseWriter, r *http.Request) {
+
+	uploads := h.zk.Circuits.Uploads()
+	if uploads == nil {
+		uploadErrorJSON(w, r, circuits.ErrUploadsDisabled)
+		return
+	}
+	offset, err := parseContentRange(r.Header.Get("Content-Range"))
+	if err != nil {
+		rest.ErrorJSON(w, r, http.StatusBadRequest, err, "illegal Content-Range", 0)
+		return
+	}
+	id := chi.URLParam(r, "id")
+	if _, err = uploads.Write(id, chi.URLParam(r, "file"), offset, r.Body); err != nil {
+		uploadErrorJSON(w, r, err)
+		return
+	}
+
+	upload, err := uploads.Get(id)
+	if err != nil {
+		uploadErrorJSON(w, r, err)
+		return
+	}
+	render.JSON(w, r, upload)
+}
+
+// DeleteUpload is a handler removing upload with its files
+// DELETE /admin/uploads/{id}
+func (h *AdminHandler) DeleteUpload(w http.ResponseWriter, r *http.Request) {
+
+	uploads := h.zk.Circuits.Uploads()
+	if uploads == nil {
+		uploadErrorJSON(w, r, circuits.ErrUploadsDisabled)
+		return
+	}
+	if err := uploads.Remove(chi.URLParam(r, "id")); err != nil {
+		uploadErrorJSON(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UploadCircuit is a handler installing circuit into circuits directory as directory {name}. Files are parts of
+// multipart/form-data body named wasm, zkey, vkey, manifest and inputs (self-test inputs), or files of upload
+// from "upload_id" query parameter, parts of the body replace files of the upload. Test proof is generated with
+// the inputs, so the circuit is published only if it works.
+// PUT /admin/circuits/{name}
+func (h *AdminHandler) UploadCircuit(w http.ResponseWriter, r *http.Request) {
+
+	uploads := h.zk.Circuits.Uploads()
+	if uploads == nil {
+		uploadErrorJSON(w, r, circuits.ErrUploadsDisabled)
+		return
+	}
+
+	id := r.URL.Query().Get("upload_id")
+	if id == "" {
+		upload, err := uploads.Create()
+		if err != nil {
+			uploadErrorJSON(w, r, err)
+			return
+		}
+		id = upload.ID
+		// files of a single request upload aren't kept
+		defer func() { _ = uploads.Remove(id) }()
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		mr, err := r.MultipartReader()
+		if err != nil {
+			rest.ErrorJSON(w, r, http.StatusBadRequest, err, "can't bind request", 0)
+			return
+		}
+		for {
+			part, err := mr.NextPart()
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					rest.ErrorJSON(w, r, http.StatusBadRequest, err, "can't bind request", 0)
+					return
+				}
+				break
+			}
+			_, err = uploads.Write(id, part.FormName(), 0, part)
+			_ = part.Close()
+			if err != nil {
+				uploadErrorJSON(w, r, err)
+				return
+			}
+		}
+	}
+
+	upload, err := uploads.Get(id)
+	if err != nil {
+		uploadErrorJSON(w, r, err)
+		return
+	}
+	c, err := h.zk.Circuits.Install(r.Context(), chi.URLParam(r, "name"), upload, h.zk.testCircuit)
+	if err != nil {
+		uploadErrorJSON(w, r, err)
+		return
+	}
+	_ = uploads.Remove(id)
+	log.WithContext(r.Context()).Infow("Circuit installed", "circuit", c.ID(), "dir", c.Dir)
+
+	render.JSON(w, r, c)
+}
+
+// testCircuit generates test proof with self-test inputs of the circuit, inputs are required
+func (h *ZKHandler) testCircuit(ctx context.Context, c circuits.Circuit) error {
+	inputs, err := readSelfTestInputs(c)
+	if os.IsNotExist(errors.Cause(err)) {
+		return errors.New("self-test inputs are required")
+	}
+	if err != nil {
+		return err
+	}
+	_, err = h.prove(ctx, c, inputs)
+	return err
+}
+
+// readSelfTestInputs reads inputs of the circuit from SelfTestInputsFile
+func readSelfTestInputs(c circuits.Circuit) (proof.ZKInputs, error) {
+	inputsBytes, err := c.ReadFile(SelfTestInputsFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read self-test inputs")
+	}
+	var inputs proof.ZKInputs
+	if err = json.Unmarshal(inputsBytes, &inputs); err != nil {
+		return nil, errors.Wrap(err, "failed to parse self-test inputs")
+	}
+	return inputs, nil
+}
+
+// parseContentRange returns start of "bytes <start>-<end>/<total>" range, it's 0 if header is empty
+func parseContentRange(header string) (int64, error) {
+	if header == "" {
+		return 0, nil
+	}
+	rng := strings.TrimPrefix(header, "bytes ")
+	i := strings.IndexByte(rng, '-')
+	if rng == header || i < 0 {
+		return 0, errors.Errorf("unsupported range %q", header)
+	}
+	start, err := strconv.ParseInt(rng[:i], 10, 64)
+	if err != nil || start < 0 {
+		return 0, errors.Errorf("illegal range start %q", header)
+	}
+	return start, nil
+}
+
+// uploadErrorJSON responds with error of circuit upload
+func uploadErrorJSON(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, circuits.ErrUploadsDisabled):
+		rest.ErrorJSON(w, r, http.StatusNotImplemented, err, "uploads aren't supported", 0)
+	case errors.Is(err, circuits.ErrUploadNotFound):
+		rest.ErrorJSON(w, r, http.StatusNotFound, err, "unknown upload", 0)
+	case errors.Is(err, circuits.ErrUploadOffset):
+		rest.ErrorJSON(w, r, http.StatusConflict, err, "illegal chunk offset", 0)
+	case errors.Is(err, circuits.ErrUploadFile), errors.Is(err, circuits.ErrIllegalName):
+		rest.ErrorJSON(w, r, http.StatusBadRequest, err, "illegal upload", 0)
+	case errors.Is(err, circuits.ErrInvalidUpload):
+		rest.ErrorJSON(w, r, http.StatusUnprocessableEntity, err, "invalid circuit", 0)
+	default:
+		rest.ErrorJSON(w, r, http.StatusInternalServerError, err, "can't upload circuit", 0)
+	}
+}
@@ -1,22 +1,72 @@
 package handlers
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"net/http"
-	"os"
-	"path"
+	"strconv"
+	"sync"
 
 	"github.com/iden3/prover-server/pkg/log"
 
 	"github.com/go-chi/render"
-	"github.com/iden3/prover-server/pkg/app/configs"
+	"github.com/iden3/go-rapidsnark/types"
+	"github.com/iden3/go-rapidsnark/verifier"
 	"github.com/iden3/prover-server/pkg/app/rest"
+	"github.com/iden3/prover-server/pkg/circuits"
+	"github.com/iden3/prover-server/pkg/client"
+	"github.com/iden3/prover-server/pkg/cluster"
+	"github.com/iden3/prover-server/pkg/idempotency"
+	"github.com/iden3/prover-server/pkg/inflight"
 	"github.com/iden3/prover-server/pkg/proof"
+	"github.com/iden3/prover-server/pkg/scheduler"
+	"github.com/iden3/prover-server/pkg/worker"
+	"github.com/pkg/errors"
+)
+
+const (
+	// MaxBatchSize is a maximum number of requests in batch proof generation
+	MaxBatchSize = 100
+	// maxBatchConcurrency limits number of proofs of one batch generated in parallel
+	maxBatchConcurrency = 4
+)
+
+var (
+	// ErrIllegalCircuitPath is returned when circuit name escapes circuits directory
+	ErrIllegalCircuitPath = circuits.ErrIllegalName
+	// ErrCircuitNotFound is returned when there is no circuit with the given name
+	ErrCircuitNotFound = circuits.ErrNotFound
+	// ErrCircuitDisabled is returned when circuit is disabled by operator
+	ErrCircuitDisabled = circuits.ErrDisabled
+	// ErrCircuitUnavailable is returned when circuit can't be fetched from remote store
+	ErrCircuitUnavailable = circuits.ErrUnavailable
+	// ErrProofTimeout is returned when proof generation exceeds timeout of the circuit
+	ErrProofTimeout = proof.ErrTimeout
+	// ErrNoWorkers is returned by coordinator when no worker of the cluster has the circuit
+	ErrNoWorkers = cluster.ErrNoWorkers
+)
+
+// Codes of error responses
+const (
+	// ErrCodeProofTimeout is a code of error response when proof generation exceeds timeout of the circuit
+	ErrCodeProofTimeout = client.ErrCodeProofTimeout
+	// ErrCodeCircuitNotFound is a code of error response when there is no circuit with the name, coordinator
+	// of cluster relies on it to stop sending the circuit to the worker
+	ErrCodeCircuitNotFound = client.ErrCodeCircuitNotFound
 )
 
 // ZKHandler is handler for zkp operations
 type ZKHandler struct {
-	ProverConfig configs.ProverConfig
+	Circuits    *circuits.Registry
+	inflight    *inflight.Group
+	progress    *proof.ProgressGroup
+	idempotency *idempotency.Store
+	workers     *worker.Pool
+	queue       *scheduler.Budget
+	cluster     *cluster.Coordinator
 }
 
 // GenerateReq is request for proof generation
@@ -25,6 +75,20 @@ type GenerateReq struct {
 	Inputs      proof.ZKInputs `json:"inputs"`
 }
 
+// Hash returns canonical hash of circuit name and inputs, identical requests have the same hash
+func (r *GenerateReq) Hash() (string, error) {
+	// json.Marshal sorts map keys, so the encoding doesn't depend on the order of inputs
+	b, err := json.Marshal(struct {
+		CircuitName string         `json:"circuit_name"`
+		Inputs      proof.ZKInputs `json:"inputs"`
+	}{r.CircuitName, r.Inputs})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to serialize request")
+	}
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:]), nil
+}
+
 // VerifyReq is request for proof verification
 type VerifyReq struct {
 	CircuitName string          `json:"circuit_name"`
@@ -36,13 +100,82 @@ type VerifyResp struct {
 	Valid bool `json:"valid"`
 }
 
-// NewZKHandler creates new instance of handler
-func NewZKHandler(proverConfig configs.ProverConfig) *ZKHandler {
+// GenerateBatchReq is request for generation of several proofs
+type GenerateBatchReq struct {
+	Requests []GenerateReq `json:"requests"`
+}
+
+// GenerateBatchResult is result of a single proof generation in the batch
+type GenerateBatchResult struct {
+	Proof *types.ZKProof `json:"proof,omitempty"`
+	Error string         `json:"error,omitempty"`
+}
+
+// GenerateBatchResp is response for batch proof generation, results are in the order of requests
+type GenerateBatchResp struct {
+	Results []GenerateBatchResult `json:"results"`
+}
+
+// Circuit is description of the circuit available on the server
+type Circuit struct {
+	// Name is name@version of the circuit, or name if the circuit has no version
+	Name          string   `json:"name"`
+	Version       string   `json:"version,omitempty"`
+	Description   string   `json:"description,omitempty"`
+	PublicSignals []string `json:"public_signals,omitempty"`
+}
+
+// CircuitsResp is response with the list of available circuits
+type CircuitsResp struct {
+	Circuits []Circuit `json:"circuits"`
+}
+
+// NewZKHandler creates new instance of handler, idempotency keys are ignored if idempotencyStore is nil
+func NewZKHandler(registry *circuits.Registry, idempotencyStore *idempotency.Store) *ZKHandler {
 	return &ZKHandler{
-		proverConfig,
+		Circuits:    registry,
+		inflight:    inflight.NewGroup(),
+		progress:    proof.NewProgressGroup(),
+		idempotency: idempotencyStore,
 	}
 }
 
+// WithWorkers makes handler generate proofs in worker processes of the pool
+func (h *ZKHandler) WithWorkers(pool *worker.Pool) *ZKHandler {
+	h.workers = pool
+	return h
+}
+
+// WithQueue makes handler generate proofs only when they are admitted by the budget, e.g. while sum of memory
+// estimates of their circuits fits into it, proofs wait in lanes of their priority
+func (h *ZKHandler) WithQueue(budget *scheduler.Budget) *ZKHandler {
+	h.queue = budget
+	return h
+}
+
+// QueueStats returns usage of the budget and depth of its lanes, nil is returned if proofs aren't queued
+func (h *ZKHandler) QueueStats() *scheduler.Stats {
+	if h.queue == nil {
+		return nil
+	}
+	stats := h.queue.Stats()
+	return &stats
+}
+
+// WithCluster makes handler dispatch proofs to workers of the cluster instead of generating them
+func (h *ZKHandler) WithCluster(coordinator *cluster.Coordinator) *ZKHandler {
+	h.cluster = coordinator
+	return h
+}
+
+// ClusterWorkers returns workers registered with coordinator, nil is returned if coordinator mode is disabled
+func (h *ZKHandler) ClusterWorkers() []cluster.WorkerStatus {
+	if h.cluster == nil {
+		return nil
+	}
+	return h.cluster.Workers()
+}
+
 // GenerateProof is a handler for proof generation
 // POST /api/v1/proof/generate
 func (h *ZKHandler) GenerateProof(w http.ResponseWriter, r *http.Request) {
@@ -53,19 +186,59 @@ func (h *ZKHandler) GenerateProof(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	log.WithContext(r.Context()).Debugw("Proof generation request", "inputs", req)
-	circuitPath, err := getValidatedCircuitPath(h.ProverConfig.CircuitsBasePath, req.CircuitName)
+
+	reqHash, err := req.Hash()
+	if err != nil {
+		rest.ErrorJSON(w, r, http.StatusBadRequest, err, "can't bind request", 0)
+		return
+	}
+
+	idempotencyKey := r.Header.Get(idempotency.HeaderKey)
+	if h.idempotency != nil && idempotencyKey != "" {
+		// key is reserved before proof generation, so that concurrent request with the key and different inputs
+		// is rejected too
+		e, err := h.idempotency.Reserve(idempotencyKey, reqHash)
+		if err != nil {
+			rest.ErrorJSON(w, r, http.StatusUnprocessableEntity, err,
+				"idempotency key was already used with different request", 0)
+			return
+		}
+		if e != nil {
+			w.Header().Set(idempotency.HeaderReplayed, "true")
+			render.JSON(w, r, e.Result)
+			return
+		}
+		// key of failed request is released, so that the request can be retried
+		defer h.idempotency.Release(idempotencyKey, reqHash)
+	}
+
+	// artifacts of the circuit are kept until proof ends, even if the circuit is reloaded meanwhile
+	circuit, release, err := h.Circuits.Acquire(r.Context(), req.CircuitName)
 	if err != nil {
-		rest.ErrorJSON(w, r, http.StatusBadRequest, err, "illegal circuitPath", 0)
+		circuitErrorJSON(w, r, err)
 		return
 	}
+	defer release()
 
-	fullProof, err := proof.GenerateZkProof(r.Context(), circuitPath, req.Inputs)
+	fullProof, err := h.generate(r.Context(), reqHash, circuit, req.Inputs)
 
+	if errors.Is(err, ErrProofTimeout) {
+		rest.ErrorJSON(w, r, http.StatusGatewayTimeout, err, "proof generation timed out", ErrCodeProofTimeout)
+		return
+	}
+	if errors.Is(err, ErrNoWorkers) {
+		rest.ErrorJSON(w, r, http.StatusServiceUnavailable, err, "no workers available", 0)
+		return
+	}
 	if err != nil {
 		rest.ErrorJSON(w, r, http.StatusInternalServerError, err, "can't generate identifier", 0)
 		return
 	}
 
+	if h.idempotency != nil && idempotencyKey != "" {
+		h.idempotency.Put(idempotencyKey, reqHash, fullProof)
+	}
+
 	render.JSON(w, r, fullProof)
 }
 
@@ -73,8 +246,6 @@ func (h *ZKHandler) GenerateProof(w http.ResponseWriter, r *http.Request) {
 // POST /api/v1/proof/verify
 func (h *ZKHandler) VerifyProof(w http.ResponseWriter, r *http.Request) {
 
-	valid := false
-
 	var req VerifyReq
 	if err := render.DecodeJSON(r.Body, &req); err != nil {
 		rest.ErrorJSON(w, r, http.StatusBadRequest, err, "can't bind request", 0)
@@ -83,35 +254,204 @@ func (h *ZKHandler) VerifyProof(w http.ResponseWriter, r *http.Request) {
 
 	log.WithContext(r.Context()).Debugw("Proof verification request", "inputs", req)
 
-	circuitPath, err := getValidatedCircuitPath(h.ProverConfig.CircuitsBasePath, req.CircuitName)
+	valid, err := h.Verify(r.Context(), req.CircuitName, &req.ZKP)
 	if err != nil {
-		rest.ErrorJSON(w, r, http.StatusBadRequest, err, "illegal circuitPath", 0)
+		circuitErrorJSON(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, VerifyResp{Valid: valid})
+}
+
+// GenerateProofBatch is a handler for generation of several proofs in one request
+// POST /api/v1/proof/generate/batch
+func (h *ZKHandler) GenerateProofBatch(w http.ResponseWriter, r *http.Request) {
+
+	var req GenerateBatchReq
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		rest.ErrorJSON(w, r, http.StatusBadRequest, err, "can't bind request", 0)
 		return
 	}
+	if len(req.Requests) > MaxBatchSize {
+		rest.ErrorJSON(w, r, http.StatusBadRequest, errors.Errorf("batch size exceeds %d", MaxBatchSize),
+			"too many requests in batch", 0)
+		return
+	}
+	log.WithContext(r.Context()).Debugw("Batch proof generation request", "size", len(req.Requests))
 
-	err = proof.VerifyZkProof(r.Context(), circuitPath, &req.ZKP)
-	if err == nil {
-		valid = true
+	resp := GenerateBatchResp{Results: make([]GenerateBatchResult, len(req.Requests))}
+	sem := make(chan struct{}, maxBatchConcurrency)
+	var wg sync.WaitGroup
+	for i := range req.Requests {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			zkp, err := h.Generate(r.Context(), req.Requests[i].CircuitName, req.Requests[i].Inputs)
+			if err != nil {
+				resp.Results[i].Error = err.Error()
+				return
+			}
+			resp.Results[i].Proof = zkp
+		}(i)
 	}
+	wg.Wait()
 
-	render.JSON(w, r, VerifyResp{Valid: valid})
+	render.JSON(w, r, resp)
 }
 
-func getValidatedCircuitPath(circuitBasePath, circuitName string) (circuitPath string, err error) {
-	// TODO: validate circuitName for illegal characters, etc
+// GetCircuits is a handler returning available circuits
+// GET /api/v1/circuits
+func (h *ZKHandler) GetCircuits(w http.ResponseWriter, r *http.Request) {
 
-	circuitPath = circuitBasePath + "/" + circuitName
+	resp := CircuitsResp{Circuits: []Circuit{}}
+	for _, c := range h.Circuits.List() {
+		if c.Disabled {
+			continue
+		}
+		resp.Circuits = append(resp.Circuits, Circuit{
+			Name:          c.ID(),
+			Version:       c.Version,
+			Description:   c.Description,
+			PublicSignals: c.PublicSignals,
+		})
+	}
+	render.JSON(w, r, resp)
+}
 
-	if path.Clean(circuitPath) != circuitPath {
-		return "", fmt.Errorf("illegal circuitPath")
+// Generate generates proof for the circuit, identical concurrent requests share a single computation
+func (h *ZKHandler) Generate(ctx context.Context, circuitName string, inputs proof.ZKInputs) (*types.ZKProof, error) {
+	req := GenerateReq{CircuitName: circuitName, Inputs: inputs}
+	key, err := req.Hash()
+	if err != nil {
+		return nil, err
 	}
 
-	fmt.Println(circuitPath)
+	circuit, release, err := h.Circuits.Acquire(ctx, circuitName)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return h.generate(ctx, key, circuit, inputs)
+}
+
+// Verify verifies proof with verification key of the circuit, error is returned only for illegal circuit
+func (h *ZKHandler) Verify(ctx context.Context, circuitName string, zkp *proof.FullProof) (bool, error) {
+	circuit, err := h.Circuits.Resolve(ctx, circuitName)
+	if err != nil {
+		return false, err
+	}
+
+	return proof.Verify(ctx, circuit.VerificationKey, zkp) == nil, nil
+}
 
-	_, err = os.Stat(circuitPath)
-	if os.IsNotExist(err) {
-		return "", fmt.Errorf("circuitPath doesn't exist")
+// ListCircuits returns name@version (or name if there is no version) of enabled circuits
+func (h *ZKHandler) ListCircuits() ([]string, error) {
+	list := h.Circuits.List()
+	names := make([]string, 0, len(list))
+	for _, c := range list {
+		if !c.Disabled {
+			names = append(names, c.ID())
+		}
 	}
+	return names, nil
+}
+
+// InFlight returns number of proofs being generated for synchronous requests, identical requests are counted once
+func (h *ZKHandler) InFlight() int {
+	return h.inflight.Len()
+}
 
-	return circuitPath, nil
+// generate runs proof generation, identical concurrent requests wait for and share a single computation,
+// its phases are reported to progress functions of all of them
+func (h *ZKHandler) generate(ctx context.Context, key string, circuit circuits.Circuit,
+	inputs proof.ZKInputs) (*types.ZKProof, error) {
+
+	// requests resolved to different circuits or versions of reloaded circuit don't share computation
+	key = fmt.Sprintf("%s/%s/%d", key, circuit.ID(), circuit.LoadedAt.UnixNano())
+	leave := h.progress.Join(ctx, key)
+	defer leave()
+	res, shared, err := h.inflight.Do(ctx, key, func(ctx context.Context) (interface{}, error) {
+		return h.prove(h.progress.WithProgress(ctx, key), circuit, inputs)
+	})
+	if shared {
+		log.WithContext(ctx).Debugw("Proof generation shared with identical request", "key", key)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return res.(*types.ZKProof), nil
+}
+
+// prove waits until the circuit is admitted by the budget and generates proof within timeout of the circuit.
+// Requests without priority wait in the lane of default priority of the circuit. Coordinator doesn't use the budget,
+// requests wait for free capacity of workers of the cluster instead.
+func (h *ZKHandler) prove(ctx context.Context, circuit circuits.Circuit, inputs proof.ZKInputs) (*types.ZKProof, error) {
+	if _, ok := scheduler.PriorityFromContext(ctx); !ok {
+		ctx = scheduler.WithPriority(ctx, circuit.Priority)
+	}
+	if h.queue != nil && h.cluster == nil {
+		release, err := h.queue.Acquire(ctx, circuit.Memory)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+
+	zkp, err := h.run(ctx, circuit, inputs)
+	if circuit.Timeout > 0 && errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+		return nil, errors.Wrapf(ErrProofTimeout, "circuit %s exceeded timeout %s", circuit.ID(), circuit.Timeout)
+	}
+	return zkp, err
+}
+
+// run generates proof with the circuit in worker process, or in the server process if workers are disabled.
+// Coordinator dispatches proof to workers of the cluster and verifies their results. Computation is cancelled
+// when all callers give up or it exceeds timeout of the circuit.
+func (h *ZKHandler) run(ctx context.Context, circuit circuits.Circuit, inputs proof.ZKInputs) (*types.ZKProof, error) {
+	if h.cluster != nil {
+		return h.cluster.Generate(ctx, circuit.ID(), inputs, circuit.Timeout, func(zkp *types.ZKProof) error {
+			return verifier.VerifyGroth16(*zkp, circuit.VerificationKey)
+		})
+	}
+	if circuit.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, circuit.Timeout)
+		defer cancel()
+	}
+	if h.workers == nil {
+		artifacts, err := h.Circuits.Artifacts(ctx, circuit)
+		if err != nil {
+			return nil, err
+		}
+		return proof.Generate(ctx, circuit.Witness, circuit.Prover, artifacts, inputs)
+	}
+	return h.workers.Generate(ctx, worker.Job{
+		Circuit:  circuit.ID(),
+		Version:  strconv.FormatInt(circuit.LoadedAt.UnixNano(), 10),
+		Location: circuit.Location,
+		Witness:  circuit.WitnessConfig,
+		Backend:  circuit.BackendConfig,
+		Inputs:   inputs,
+	})
+}
+
+// circuitErrorJSON responds with error of circuit lookup
+func circuitErrorJSON(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, ErrCircuitDisabled) {
+		rest.ErrorJSON(w, r, http.StatusServiceUnavailable, err, "circuit is disabled", 0)
+		return
+	}
+	if errors.Is(err, ErrCircuitUnavailable) {
+		rest.ErrorJSON(w, r, http.StatusServiceUnavailable, err, "circuit is unavailable", 0)
+		return
+	}
+	if errors.Is(err, ErrCircuitNotFound) {
+		rest.ErrorJSON(w, r, http.StatusBadRequest, err, "illegal circuitPath", ErrCodeCircuitNotFound)
+		return
+	}
+	rest.ErrorJSON(w, r, http.StatusBadRequest, err, "illegal circuitPath", 0)
 }
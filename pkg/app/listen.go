@@ -0,0 +1,31 @@
+package app
+
+import (
+	"net"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// unixPrefix marks address as a path of unix domain socket
+const unixPrefix = "unix:"
+
+// Listen announces on the address, it's either tcp "host:port" or "unix:/path/to/socket"
+func Listen(address string) (net.Listener, error) {
+	if !strings.HasPrefix(address, unixPrefix) {
+		lis, err := net.Listen("tcp", address)
+		return lis, errors.Wrapf(err, "failed to listen on %s", address)
+	}
+
+	socketPath := strings.TrimPrefix(address, unixPrefix)
+	// remove socket left by previous run, other files are not touched
+	if fi, err := os.Stat(socketPath); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if err = os.Remove(socketPath); err != nil {
+			return nil, errors.Wrapf(err, "failed to remove stale socket %s", socketPath)
+		}
+	}
+
+	lis, err := net.Listen("unix", socketPath)
+	return lis, errors.Wrapf(err, "failed to listen on %s", address)
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+
+	"github.com/iden3/prover-server/pkg/app/rest"
+	"github.com/pkg/errors"
+)
+
+type clientCertKey struct{}
+
+// ClientCertificate is a middleware that puts verified client certificate of mutual TLS connection
+// into request context, so it is available to authorization middlewares and handlers
+func ClientCertificate(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+			ctx := context.WithValue(r.Context(), clientCertKey{}, r.TLS.VerifiedChains[0][0])
+			r = r.WithContext(ctx)
+		}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// ClientCertFromContext returns verified client certificate of the request or nil if there is none
+func ClientCertFromContext(ctx context.Context) *x509.Certificate {
+	cert, _ := ctx.Value(clientCertKey{}).(*x509.Certificate)
+	return cert
+}
+
+// RequireClientCN is a middleware that serves only requests with client certificate which subject
+// common name is in the allowed list, all requests are allowed if the list is empty
+func RequireClientCN(allowed []string) func(http.Handler) http.Handler {
+	allowedSet := make(map[string]bool, len(allowed))
+	for _, cn := range allowed {
+		allowedSet[cn] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		if len(allowedSet) == 0 {
+			return next
+		}
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			cert := ClientCertFromContext(r.Context())
+			if cert == nil {
+				rest.ErrorJSON(w, r, http.StatusUnauthorized, errors.New("client certificate required"), "unauthorized", 0)
+				return
+			}
+			if !allowedSet[cert.Subject.CommonName] {
+				rest.ErrorJSON(w, r, http.StatusForbidden, errors.Errorf("client %q is not allowed", cert.Subject.CommonName),
+					"forbidden", 0)
+				return
+			}
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
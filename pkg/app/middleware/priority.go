@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/iden3/prover-server/pkg/app/rest"
+	"github.com/iden3/prover-server/pkg/scheduler"
+)
+
+// Priority is a middleware that puts priority of the request resolved from X-Priority header, client certificate
+// and X-API-Key header into request context, requests without priority get default priority of the circuit
+func Priority(resolver *scheduler.Resolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if resolver == nil {
+			return next
+		}
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			var clientCN string
+			if cert := ClientCertFromContext(r.Context()); cert != nil {
+				clientCN = cert.Subject.CommonName
+			}
+			p, ok, err := resolver.Resolve(r.Header.Get(scheduler.HeaderPriority), clientCN,
+				r.Header.Get(scheduler.HeaderAPIKey))
+			if err != nil {
+				rest.ErrorJSON(w, r, http.StatusBadRequest, err, "illegal priority", 0)
+				return
+			}
+			if ok {
+				r = r.WithContext(scheduler.WithPriority(r.Context(), p))
+			}
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,18 @@
+package openapi
+
+import (
+	_ "embed" // embeds OpenAPI document
+	"net/http"
+)
+
+// Spec is OpenAPI document describing REST API of the server
+//
+//go:embed openapi.json
+var Spec []byte
+
+// Handler serves OpenAPI document
+// GET /api/v1/openapi.json
+func Handler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(Spec)
+}
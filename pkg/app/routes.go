@@ -3,6 +3,8 @@ package app
 import (
 	"github.com/iden3/prover-server/pkg/app/handlers"
 	customMiddleware "github.com/iden3/prover-server/pkg/app/middleware"
+	"github.com/iden3/prover-server/pkg/app/openapi"
+	"github.com/iden3/prover-server/pkg/scheduler"
 
 	"net/http"
 
@@ -15,7 +17,16 @@ import (
 // Handlers contain supported handlers by server
 type Handlers struct {
 	/* Put handlers here*/
-	ZKHandler *handlers.ZKHandler
+	ZKHandler   *handlers.ZKHandler
+	JobsHandler *handlers.JobsHandler
+	// ClusterHandler serves heartbeats of workers, it's nil if coordinator mode is disabled
+	ClusterHandler *handlers.ClusterHandler
+
+	// AllowedClientCNs restricts proof and circuit routes to mutual TLS clients with these certificate
+	// common names, the routes are open if it's empty
+	AllowedClientCNs []string
+	// Priorities resolve priority of proof requests, requests get default priority of circuits if it's nil
+	Priorities *scheduler.Resolver
 }
 
 // Routes initializes router
@@ -27,12 +38,14 @@ func (s *Handlers) Routes() chi.Router {
 	corsHandler := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "Idempotency-Key", "X-Priority", "X-API-Key"},
+		ExposedHeaders:   []string{"Idempotent-Replayed"},
 		AllowCredentials: true,
 	})
 	r.Use(corsHandler.Handler)
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
+	r.Use(customMiddleware.ClientCertificate)
 	r.Use(customMiddleware.ZapContextLogger)
 	r.Use(middleware.Recoverer)
 	r.Route("/api/v1", func(api chi.Router) {
@@ -46,11 +59,32 @@ func (s *Handlers) Routes() chi.Router {
 			}{Status: "up and running"})
 		})
 
-		// identity routes, require auth and admin users only
-		api.Route("/proof", func(rr chi.Router) {
-			rr.Post("/generate", s.ZKHandler.GenerateProof)
-			rr.Post("/verify", s.ZKHandler.VerifyProof)
+		api.Get("/openapi.json", openapi.Handler)
+
+		api.Group(func(authorized chi.Router) {
+			authorized.Use(customMiddleware.RequireClientCN(s.AllowedClientCNs))
+			authorized.Use(customMiddleware.Priority(s.Priorities))
+
+			authorized.Get("/circuits", s.ZKHandler.GetCircuits)
+
+			// identity routes, require auth and admin users only
+			authorized.Route("/proof", func(rr chi.Router) {
+				rr.Post("/generate", s.ZKHandler.GenerateProof)
+				rr.Post("/generate/batch", s.ZKHandler.GenerateProofBatch)
+				rr.Post("/verify", s.ZKHandler.VerifyProof)
+				rr.Post("/jobs", s.JobsHandler.SubmitJob)
+				rr.Get("/jobs/{id}", s.JobsHandler.GetJob)
+				rr.Get("/jobs/{id}/events", s.JobsHandler.JobEvents)
+			})
 		})
+
+		// heartbeats are authenticated by cluster token, they don't need priority of proof requests
+		if s.ClusterHandler != nil {
+			api.Group(func(cluster chi.Router) {
+				cluster.Use(customMiddleware.RequireClientCN(s.AllowedClientCNs))
+				cluster.Post("/cluster/heartbeat", s.ClusterHandler.Heartbeat)
+			})
+		}
 	})
 
 	return r
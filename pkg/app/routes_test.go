@@ -0,0 +1,361 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/iden3/go-rapidsnark/types"
+	"github.com/iden3/go-rapidsnark/verifier"
+	"github.com/iden3/prover-server/pkg/app/configs"
+	"github.com/iden3/prover-server/pkg/app/handlers"
+	"github.com/iden3/prover-server/pkg/app/openapi"
+	"github.com/iden3/prover-server/pkg/circuits"
+	"github.com/iden3/prover-server/pkg/circuits/circuitstest"
+	"github.com/iden3/prover-server/pkg/cluster"
+	"github.com/iden3/prover-server/pkg/idempotency"
+	"github.com/iden3/prover-server/pkg/jobs"
+	"github.com/iden3/prover-server/pkg/proof"
+	"github.com/iden3/prover-server/pkg/scheduler"
+	"github.com/stretchr/testify/require"
+)
+
+type openAPIDoc struct {
+	Paths map[string]map[string]json.RawMessage `json:"paths"`
+}
+
+func TestRoutesDescribedInOpenAPI(t *testing.T) {
+
+	var doc openAPIDoc
+	require.NoError(t, json.Unmarshal(openapi.Spec, &doc))
+
+	// all routes are registered in coordinator mode
+	h := Handlers{ClusterHandler: handlers.NewClusterHandler(nil, "")}
+	described := 0
+	err := chi.Walk(h.Routes(), func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		ops, ok := doc.Paths[route]
+		require.Truef(t, ok, "route %s is not described in OpenAPI document", route)
+		_, ok = ops[strings.ToLower(method)]
+		require.Truef(t, ok, "%s %s is not described in OpenAPI document", method, route)
+		described++
+		return nil
+	})
+	require.NoError(t, err)
+
+	operations := 0
+	for _, ops := range doc.Paths {
+		operations += len(ops)
+	}
+	require.Equal(t, operations, described, "OpenAPI document describes routes that are not registered")
+}
+
+func TestOpenAPIServed(t *testing.T) {
+
+	handlers := Handlers{}
+	srv := httptest.NewServer(handlers.Routes())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/api/v1/openapi.json")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var doc map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&doc))
+	require.Equal(t, "3.1.0", doc["openapi"])
+}
+
+func TestPriorities(t *testing.T) {
+	circuitsPath := t.TempDir()
+	circuitstest.WriteCircuit(t, circuitsPath, "auth")
+	registry, err := circuits.NewRegistry(configs.ProverConfig{CircuitsBasePath: circuitsPath})
+	require.NoError(t, err)
+	defer registry.Close()
+	priorities, err := scheduler.NewResolver(configs.PriorityConfig{Clients: []configs.ClientPriorityConfig{
+		{APIKey: "issuer-key", Priority: "low"},
+		{APIKey: "wallet-key", Priority: "high"},
+	}})
+	require.NoError(t, err)
+
+	// jobs aren't started, so they stay queued
+	manager := jobs.NewManager(jobs.NewMemoryStore(), nil, jobs.Options{})
+	apiHandlers := Handlers{
+		ZKHandler:   handlers.NewZKHandler(registry, nil),
+		JobsHandler: handlers.NewJobsHandler(registry, manager),
+		Priorities:  priorities,
+	}
+	srv := httptest.NewServer(apiHandlers.Routes())
+	defer srv.Close()
+
+	submit := func(priority, apiKey string) (int, scheduler.Priority) {
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/api/v1/proof/jobs",
+			strings.NewReader(`{"circuit_name":"auth","inputs":{}}`))
+		require.NoError(t, err)
+		req.Header.Set(scheduler.HeaderPriority, priority)
+		req.Header.Set(scheduler.HeaderAPIKey, apiKey)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var job jobs.Job
+		_ = json.NewDecoder(resp.Body).Decode(&job)
+		return resp.StatusCode, job.Priority
+	}
+
+	code, p := submit("high", "wallet-key")
+	require.Equal(t, http.StatusAccepted, code)
+	require.Equal(t, scheduler.PriorityHigh, p)
+	// unknown clients can't request priority higher than the default one
+	_, p = submit("high", "")
+	require.Equal(t, scheduler.PriorityNormal, p)
+	// priority of client is used by default and it caps requested priority
+	_, p = submit("", "issuer-key")
+	require.Equal(t, scheduler.PriorityLow, p)
+	_, p = submit("high", "issuer-key")
+	require.Equal(t, scheduler.PriorityLow, p)
+	// circuit default is used for requests without priority
+	_, p = submit("", "")
+	require.Empty(t, p)
+
+	code, _ = submit("urgent", "")
+	require.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestCluster(t *testing.T) {
+	circuitsPath := t.TempDir()
+	circuitstest.WriteCircuit(t, circuitsPath, "auth")
+	registry, err := circuits.NewRegistry(configs.ProverConfig{CircuitsBasePath: circuitsPath})
+	require.NoError(t, err)
+	defer registry.Close()
+
+	coordinator, err := cluster.NewCoordinator(configs.ClusterConfig{})
+	require.NoError(t, err)
+	zk := handlers.NewZKHandler(registry, nil).WithCluster(coordinator)
+	priorities, err := scheduler.NewResolver(configs.PriorityConfig{})
+	require.NoError(t, err)
+	apiHandlers := Handlers{
+		ZKHandler:      zk,
+		ClusterHandler: handlers.NewClusterHandler(coordinator, "secret"),
+		Priorities:     priorities,
+	}
+	srv := httptest.NewServer(apiHandlers.Routes())
+	defer srv.Close()
+
+	post := func(path, token, body string) int {
+		req, err := http.NewRequest(http.MethodPost, srv.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// proofs aren't generated by coordinator itself
+	require.Equal(t, http.StatusServiceUnavailable,
+		post("/api/v1/proof/generate", "", `{"circuit_name":"auth","inputs":{}}`))
+
+	heartbeat := `{"id":"worker1","url":"http://worker1:8002","circuits":["auth"]}`
+	require.Equal(t, http.StatusUnauthorized, post(cluster.HeartbeatPath, "wrong", heartbeat))
+	require.Equal(t, http.StatusBadRequest, post(cluster.HeartbeatPath, "secret", `{"id":"worker1"}`))
+	require.Equal(t, http.StatusBadRequest,
+		post(cluster.HeartbeatPath, "secret", `{"id":"worker1","url":"file:///etc/passwd","circuits":["auth"]}`))
+	require.Equal(t, http.StatusNoContent, post(cluster.HeartbeatPath, "secret", heartbeat))
+	require.Len(t, zk.ClusterWorkers(), 1)
+
+	// heartbeats don't go through priority of proof requests
+	req, err := http.NewRequest(http.MethodPost, srv.URL+cluster.HeartbeatPath, strings.NewReader(heartbeat))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set(scheduler.HeaderPriority, "urgent")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	// heartbeats are rejected without cluster token
+	noToken := httptest.NewServer((&Handlers{ZKHandler: zk, ClusterHandler: handlers.NewClusterHandler(coordinator, "")}).Routes())
+	defer noToken.Close()
+	resp, err = http.Post(noToken.URL+cluster.HeartbeatPath, "application/json", strings.NewReader(heartbeat))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// heartbeats are rejected by server which isn't coordinator
+	worker := httptest.NewServer((&Handlers{ZKHandler: handlers.NewZKHandler(registry, nil)}).Routes())
+	defer worker.Close()
+	resp, err = http.Post(worker.URL+cluster.HeartbeatPath, "application/json", strings.NewReader(heartbeat))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestIdempotency(t *testing.T) {
+	circuitsPath := t.TempDir()
+	circuitstest.WriteCircuit(t, circuitsPath, "auth")
+	registry, err := circuits.NewRegistry(configs.ProverConfig{CircuitsBasePath: circuitsPath})
+	require.NoError(t, err)
+	defer registry.Close()
+
+	// the only slot of the queue is taken, so that proof generation waits until it's released
+	queue := scheduler.NewBudget(0).WithConcurrency(1)
+	release, err := queue.Acquire(context.Background(), 0)
+	require.NoError(t, err)
+	store := idempotency.NewStore(time.Minute)
+	apiHandlers := Handlers{ZKHandler: handlers.NewZKHandler(registry, store).WithQueue(queue)}
+	srv := httptest.NewServer(apiHandlers.Routes())
+	defer srv.Close()
+
+	generate := func(key, inputs string) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/api/v1/proof/generate",
+			strings.NewReader(`{"circuit_name":"auth","inputs":`+inputs+`}`))
+		require.NoError(t, err)
+		req.Header.Set(idempotency.HeaderKey, key)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	// key is reserved by running request
+	first := make(chan int)
+	go func() { first <- generate("key1", `{"a":1}`).StatusCode }()
+	require.Eventually(t, func() bool { return queue.Stats().Queued == 1 }, time.Second, 10*time.Millisecond)
+	require.Equal(t, http.StatusUnprocessableEntity, generate("key1", `{"a":2}`).StatusCode)
+
+	// key of failed request is released
+	release()
+	require.Equal(t, http.StatusInternalServerError, <-first)
+	require.Equal(t, http.StatusInternalServerError, generate("key1", `{"a":2}`).StatusCode)
+
+	// result of completed request is replayed
+	hash, err := (&handlers.GenerateReq{CircuitName: "auth", Inputs: map[string]interface{}{"a": 1.0}}).Hash()
+	require.NoError(t, err)
+	store.Put("key2", hash, types.ZKProof{PubSignals: []string{"1"}})
+	resp := generate("key2", `{"a":1}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "true", resp.Header.Get(idempotency.HeaderReplayed))
+	var zkp types.ZKProof
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&zkp))
+	require.Equal(t, []string{"1"}, zkp.PubSignals)
+	require.Equal(t, http.StatusUnprocessableEntity, generate("key2", `{"a":2}`).StatusCode)
+}
+
+func TestReloadBetweenResolveAndGenerate(t *testing.T) {
+	circuitsPath := t.TempDir()
+	circuitPath := circuitstest.WriteMultiplier(t, circuitsPath, "multiplier")
+	registry, err := circuits.NewRegistry(configs.ProverConfig{
+		CircuitsBasePath: circuitsPath,
+		Backend:          configs.ProverBackendConfig{Type: proof.BackendGnark},
+		Witness:          configs.WitnessConfig{Type: proof.WitnessWazero},
+	})
+	require.NoError(t, err)
+	defer registry.Close()
+
+	// the only slot of the queue is taken, so that resolved request waits until it's released
+	queue := scheduler.NewBudget(0).WithConcurrency(1)
+	release, err := queue.Acquire(context.Background(), 0)
+	require.NoError(t, err)
+	apiHandlers := Handlers{ZKHandler: handlers.NewZKHandler(registry, nil).WithQueue(queue)}
+	srv := httptest.NewServer(apiHandlers.Routes())
+	defer srv.Close()
+
+	generate := func() *http.Response {
+		resp, err := http.Post(srv.URL+"/api/v1/proof/generate", "application/json",
+			strings.NewReader(`{"circuit_name":"multiplier","inputs":{"x":3,"y":5}}`))
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+	first := make(chan *http.Response)
+	go func() { first <- generate() }()
+	require.Eventually(t, func() bool { return queue.Stats().Queued == 1 }, time.Second, 10*time.Millisecond)
+
+	// circuit is replaced by version which can't generate proofs while the request waits
+	require.NoError(t, os.WriteFile(filepath.Join(circuitPath, proof.ZKeyFile),
+		circuitstest.Artifacts[proof.ZKeyFile], 0o600))
+	require.NoError(t, registry.Reload())
+
+	// request generates proof with the version it was resolved to
+	release()
+	resp := <-first
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var zkp types.ZKProof
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&zkp))
+	require.Equal(t, []string{"15", "8"}, zkp.PubSignals)
+	vkey, err := os.ReadFile(filepath.Join(circuitPath, proof.VerificationKeyFile))
+	require.NoError(t, err)
+	require.NoError(t, verifier.VerifyGroth16(zkp, vkey))
+
+	// new requests use the new version
+	require.Equal(t, http.StatusInternalServerError, generate().StatusCode)
+}
+
+func TestJobEvents(t *testing.T) {
+	circuitsPath := t.TempDir()
+	circuitstest.WriteCircuit(t, circuitsPath, "auth")
+	registry, err := circuits.NewRegistry(configs.ProverConfig{CircuitsBasePath: circuitsPath})
+	require.NoError(t, err)
+	defer registry.Close()
+
+	// the only slot of the queue is taken, so that jobs wait until it's released
+	queue := scheduler.NewBudget(0).WithConcurrency(1)
+	release, err := queue.Acquire(context.Background(), 0)
+	require.NoError(t, err)
+	zk := handlers.NewZKHandler(registry, nil).WithQueue(queue)
+	manager := jobs.NewManager(jobs.NewMemoryStore(), zk.Generate, jobs.Options{Workers: 2})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, manager.Start(ctx))
+	apiHandlers := Handlers{ZKHandler: zk, JobsHandler: handlers.NewJobsHandler(registry, manager)}
+	srv := httptest.NewServer(apiHandlers.Routes())
+	defer srv.Close()
+
+	// identical jobs share computation, both of them get its phases
+	streams := make([]*bufio.Scanner, 2)
+	for i := range streams {
+		resp, err := http.Post(srv.URL+"/api/v1/proof/jobs", "application/json",
+			strings.NewReader(`{"circuit_name":"auth","inputs":{"a":1}}`))
+		require.NoError(t, err)
+		var job jobs.Job
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&job))
+		resp.Body.Close()
+
+		resp, err = http.Get(srv.URL + "/api/v1/proof/jobs/" + job.ID + "/events")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+		streams[i] = bufio.NewScanner(resp.Body)
+	}
+	require.Eventually(t, func() bool { return queue.Stats().Queued == 1 && manager.Stats().Running == 2 },
+		time.Second, 10*time.Millisecond)
+	release()
+
+	for _, stream := range streams {
+		var events []string
+		var job jobs.Job
+		for stream.Scan() {
+			line := stream.Text()
+			if strings.HasPrefix(line, "event: ") {
+				events = append(events, strings.TrimPrefix(line, "event: "))
+			}
+			if strings.HasPrefix(line, "data: ") {
+				require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &job))
+				if job.Phase != "" {
+					require.Equal(t, proof.PhaseWitness, job.Phase)
+				}
+			}
+		}
+		require.Contains(t, events, "phase")
+		require.Equal(t, "result", events[len(events)-1])
+		// placeholder circuit can't generate proof
+		require.Equal(t, jobs.StatusFailed, job.Status)
+	}
+}
@@ -0,0 +1,65 @@
+package rpc
+
+import (
+	"context"
+	"crypto/x509"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// RequireClientCN returns server options serving only calls with client certificate which subject common name
+// is in the allowed list, the same as RequireClientCN middleware does for REST API. All calls are allowed if the
+// list is empty.
+func RequireClientCN(allowed []string) []grpc.ServerOption {
+	if len(allowed) == 0 {
+		return nil
+	}
+	allowedSet := make(map[string]bool, len(allowed))
+	for _, cn := range allowed {
+		allowedSet[cn] = true
+	}
+
+	authorize := func(ctx context.Context) error {
+		cert := clientCert(ctx)
+		if cert == nil {
+			return status.Error(codes.Unauthenticated, "client certificate required")
+		}
+		if !allowedSet[cert.Subject.CommonName] {
+			return status.Errorf(codes.PermissionDenied, "client %q is not allowed", cert.Subject.CommonName)
+		}
+		return nil
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo,
+			handler grpc.UnaryHandler) (interface{}, error) {
+			if err := authorize(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv interface{}, stream grpc.ServerStream, _ *grpc.StreamServerInfo,
+			handler grpc.StreamHandler) error {
+			if err := authorize(stream.Context()); err != nil {
+				return err
+			}
+			return handler(srv, stream)
+		}),
+	}
+}
+
+// clientCert returns verified client certificate of mutual TLS connection or nil if there is none
+func clientCert(ctx context.Context) *x509.Certificate {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return info.State.VerifiedChains[0][0]
+}
@@ -0,0 +1,95 @@
+package rpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	proverv1 "github.com/iden3/prover-server/pkg/api/prover/v1"
+	"github.com/iden3/prover-server/pkg/app/configs"
+	"github.com/iden3/prover-server/pkg/app/handlers"
+	"github.com/iden3/prover-server/pkg/circuits"
+	"github.com/iden3/prover-server/pkg/circuits/circuitstest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newCert creates certificate with common name cn signed by parent, or self-signed CA certificate if parent is nil
+func newCert(t *testing.T, cn string, parent *tls.Certificate) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		DNSNames:              []string{cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  parent == nil,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	parentCert, parentKey := tmpl, interface{}(key)
+	if parent != nil {
+		parentCert, parentKey = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parentCert, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func TestRequireClientCN(t *testing.T) {
+	circuitsPath := t.TempDir()
+	circuitstest.WriteCircuit(t, circuitsPath, "auth")
+	registry, err := circuits.NewRegistry(configs.ProverConfig{CircuitsBasePath: circuitsPath})
+	require.NoError(t, err)
+	defer registry.Close()
+
+	ca := newCert(t, "ca", nil)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Leaf)
+	serverCreds := credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{newCert(t, "prover", &ca)},
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+		ClientCAs:    pool,
+		MinVersion:   tls.VersionTLS12,
+	})
+	opts := append([]grpc.ServerOption{grpc.Creds(serverCreds)}, RequireClientCN([]string{"issuer"})...)
+	srv := NewServer(handlers.NewZKHandler(registry, nil), opts...)
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	listCircuits := func(certs ...tls.Certificate) error {
+		conn, err := grpc.Dial("bufnet",
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+			grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+				Certificates: certs,
+				RootCAs:      pool,
+				ServerName:   "prover",
+				MinVersion:   tls.VersionTLS12,
+			})))
+		require.NoError(t, err)
+		defer conn.Close()
+		_, err = proverv1.NewProverServiceClient(conn).ListCircuits(context.Background(), &proverv1.ListCircuitsRequest{})
+		return err
+	}
+
+	require.NoError(t, listCircuits(newCert(t, "issuer", &ca)))
+	require.Equal(t, codes.PermissionDenied, status.Code(listCircuits(newCert(t, "other", &ca))))
+	require.Equal(t, codes.Unauthenticated, status.Code(listCircuits()))
+}
@@ -0,0 +1,67 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/iden3/prover-server/pkg/scheduler"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// WithPriorities returns server options putting priority of calls resolved from x-priority metadata, client
+// certificate and x-api-key metadata into call context
+func WithPriorities(resolver *scheduler.Resolver) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo,
+			handler grpc.UnaryHandler) (interface{}, error) {
+			ctx, err := withPriority(ctx, resolver)
+			if err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv interface{}, stream grpc.ServerStream, _ *grpc.StreamServerInfo,
+			handler grpc.StreamHandler) error {
+			ctx, err := withPriority(stream.Context(), resolver)
+			if err != nil {
+				return err
+			}
+			return handler(srv, &priorityStream{ServerStream: stream, ctx: ctx})
+		}),
+	}
+}
+
+// priorityStream is a server stream with context carrying priority
+type priorityStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *priorityStream) Context() context.Context {
+	return s.ctx
+}
+
+func withPriority(ctx context.Context, resolver *scheduler.Resolver) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	first := func(key string) string {
+		if values := md.Get(strings.ToLower(key)); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+	var clientCN string
+	if cert := clientCert(ctx); cert != nil {
+		clientCN = cert.Subject.CommonName
+	}
+	p, ok, err := resolver.Resolve(first(scheduler.HeaderPriority), clientCN, first(scheduler.HeaderAPIKey))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if ok {
+		ctx = scheduler.WithPriority(ctx, p)
+	}
+	return ctx, nil
+}
@@ -0,0 +1,196 @@
+package rpc
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/iden3/go-rapidsnark/types"
+	proverv1 "github.com/iden3/prover-server/pkg/api/prover/v1"
+	"github.com/iden3/prover-server/pkg/app/handlers"
+	"github.com/iden3/prover-server/pkg/log"
+	"github.com/iden3/prover-server/pkg/proof"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxStreamConcurrency limits number of proofs generated in parallel for one GenerateStream call
+const maxStreamConcurrency = 4
+
+// ProverServer implements gRPC ProverService on top of ZKHandler business logic
+type ProverServer struct {
+	proverv1.UnimplementedProverServiceServer
+	zk *handlers.ZKHandler
+}
+
+// NewServer creates gRPC server with registered ProverService
+func NewServer(zk *handlers.ZKHandler, opts ...grpc.ServerOption) *grpc.Server {
+	s := grpc.NewServer(opts...)
+	proverv1.RegisterProverServiceServer(s, &ProverServer{zk: zk})
+	return s
+}
+
+// Generate generates proof for the circuit
+func (s *ProverServer) Generate(ctx context.Context, req *proverv1.GenerateRequest) (*proverv1.GenerateResponse, error) {
+	zkp, err := s.generate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &proverv1.GenerateResponse{Proof: zkp}, nil
+}
+
+// Verify verifies proof with verification key of the circuit
+func (s *ProverServer) Verify(ctx context.Context, req *proverv1.VerifyRequest) (*proverv1.VerifyResponse, error) {
+	log.WithContext(ctx).Debugw("gRPC proof verification request", "circuit", req.CircuitName)
+
+	valid, err := s.zk.Verify(ctx, req.CircuitName, fromProto(req.Zkp))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &proverv1.VerifyResponse{Valid: valid}, nil
+}
+
+// GenerateStream generates proofs for every request received from the stream and sends them back
+// as soon as they are ready, response index is a position of the request in the stream
+func (s *ProverServer) GenerateStream(stream proverv1.ProverService_GenerateStreamServer) error {
+	ctx := stream.Context()
+
+	var (
+		wg      sync.WaitGroup
+		sendMu  sync.Mutex
+		sendErr error
+	)
+	sem := make(chan struct{}, maxStreamConcurrency)
+
+	send := func(resp *proverv1.GenerateStreamResponse) {
+		sendMu.Lock()
+		defer sendMu.Unlock()
+		if sendErr == nil {
+			sendErr = stream.Send(resp)
+		}
+	}
+
+	var recvErr error
+	for index := uint32(0); ; index++ {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			recvErr = err
+			break
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		}
+
+		wg.Add(1)
+		go func(index uint32, req *proverv1.GenerateRequest) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			resp := &proverv1.GenerateStreamResponse{Index: index}
+			zkp, err := s.generate(ctx, req)
+			if err != nil {
+				resp.Error = err.Error()
+			} else {
+				resp.Proof = zkp
+			}
+			send(resp)
+		}(index, req)
+	}
+
+	wg.Wait()
+	if recvErr != nil {
+		return recvErr
+	}
+	return sendErr
+}
+
+// ListCircuits returns circuits available on the server
+func (s *ProverServer) ListCircuits(ctx context.Context, _ *proverv1.ListCircuitsRequest) (*proverv1.ListCircuitsResponse, error) {
+	names, err := s.zk.ListCircuits()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &proverv1.ListCircuitsResponse{Circuits: make([]*proverv1.Circuit, len(names))}
+	for i, name := range names {
+		resp.Circuits[i] = &proverv1.Circuit{Name: name}
+	}
+	return resp, nil
+}
+
+func (s *ProverServer) generate(ctx context.Context, req *proverv1.GenerateRequest) (*proverv1.ZKProof, error) {
+	log.WithContext(ctx).Debugw("gRPC proof generation request", "circuit", req.CircuitName)
+
+	var inputs proof.ZKInputs
+	if req.Inputs != nil {
+		inputs = req.Inputs.AsMap()
+	}
+
+	zkp, err := s.zk.Generate(ctx, req.CircuitName, inputs)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toProto(zkp), nil
+}
+
+// toStatus converts errors of ZKHandler to gRPC status
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, handlers.ErrIllegalCircuitPath):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, handlers.ErrCircuitNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, handlers.ErrCircuitDisabled), errors.Is(err, handlers.ErrCircuitUnavailable),
+		errors.Is(err, handlers.ErrNoWorkers):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, handlers.ErrProofTimeout):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func toProto(zkp *types.ZKProof) *proverv1.ZKProof {
+	res := &proverv1.ZKProof{PubSignals: zkp.PubSignals}
+	if zkp.Proof != nil {
+		res.Proof = &proverv1.ProofData{
+			A:        zkp.Proof.A,
+			C:        zkp.Proof.C,
+			Protocol: zkp.Proof.Protocol,
+		}
+		for _, b := range zkp.Proof.B {
+			res.Proof.B = append(res.Proof.B, &proverv1.StringList{Values: b})
+		}
+	}
+	return res
+}
+
+func fromProto(zkp *proverv1.ZKProof) *proof.FullProof {
+	res := &proof.FullProof{Proof: &proof.ZKProof{}}
+	if zkp == nil {
+		return res
+	}
+	res.PubSignals = zkp.PubSignals
+	if zkp.Proof != nil {
+		res.Proof.A = zkp.Proof.A
+		res.Proof.C = zkp.Proof.C
+		res.Proof.Protocol = zkp.Proof.Protocol
+		for _, b := range zkp.Proof.B {
+			res.Proof.B = append(res.Proof.B, b.Values)
+		}
+	}
+	return res
+}
@@ -0,0 +1,108 @@
+package rpc
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	proverv1 "github.com/iden3/prover-server/pkg/api/prover/v1"
+	"github.com/iden3/prover-server/pkg/app/configs"
+	"github.com/iden3/prover-server/pkg/app/handlers"
+	"github.com/iden3/prover-server/pkg/circuits"
+	"github.com/iden3/prover-server/pkg/circuits/circuitstest"
+	"github.com/iden3/prover-server/pkg/proof"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newTestClient(t *testing.T) proverv1.ProverServiceClient {
+	return newTestClientWithConfig(t, configs.ProverConfig{})
+}
+
+func newTestClientWithConfig(t *testing.T, config configs.ProverConfig) proverv1.ProverServiceClient {
+	config.CircuitsBasePath = t.TempDir()
+	circuitstest.WriteCircuit(t, config.CircuitsBasePath, "auth")
+	registry, err := circuits.NewRegistry(config)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = registry.Close() })
+
+	zk := handlers.NewZKHandler(registry, nil)
+	srv := NewServer(zk)
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return proverv1.NewProverServiceClient(conn)
+}
+
+func TestListCircuits(t *testing.T) {
+	client := newTestClient(t)
+
+	resp, err := client.ListCircuits(context.Background(), &proverv1.ListCircuitsRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.Circuits, 1)
+	require.Equal(t, "auth", resp.Circuits[0].Name)
+}
+
+func TestGenerateUnknownCircuit(t *testing.T) {
+	client := newTestClient(t)
+
+	_, err := client.Generate(context.Background(), &proverv1.GenerateRequest{CircuitName: "unknown"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.Verify(context.Background(), &proverv1.VerifyRequest{CircuitName: "../auth"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGenerateStream(t *testing.T) {
+	client := newTestClient(t)
+
+	stream, err := client.GenerateStream(context.Background())
+	require.NoError(t, err)
+	for _, name := range []string{"unknown1", "unknown2"} {
+		require.NoError(t, stream.Send(&proverv1.GenerateRequest{CircuitName: name}))
+	}
+	require.NoError(t, stream.CloseSend())
+
+	indexes := map[uint32]bool{}
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		require.Contains(t, resp.Error, "doesn't exist")
+		indexes[resp.Index] = true
+	}
+	require.Equal(t, map[uint32]bool{0: true, 1: true}, indexes)
+}
+
+func TestGenerateTimeout(t *testing.T) {
+	// witness generator hangs, so proof generation exceeds timeout of the circuit
+	bin := filepath.Join(t.TempDir(), "auth")
+	require.NoError(t, os.WriteFile(bin, []byte("#!/bin/sh\nexec sleep 10\n"), 0o700))
+	client := newTestClientWithConfig(t, configs.ProverConfig{
+		Witness:  configs.WitnessConfig{Type: proof.WitnessBinary, Path: bin},
+		Timeouts: []configs.CircuitTimeoutConfig{{Circuit: "auth", Timeout: 100 * time.Millisecond}},
+	})
+
+	start := time.Now()
+	_, err := client.Generate(context.Background(), &proverv1.GenerateRequest{CircuitName: "auth"})
+	require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	require.Contains(t, status.Convert(err).Message(), "timed out")
+	require.Less(t, time.Since(start), 5*time.Second)
+}
@@ -1,15 +1,30 @@
 package app
 
 import (
-	"fmt"
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
 	"github.com/go-chi/chi"
 	"github.com/iden3/prover-server/pkg/log"
-	"net/http"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
 )
 
+// shutdownTimeout limits how long requests being served are waited for on shutdown
+const shutdownTimeout = 10 * time.Second
+
 // Server instance of chi server
 type Server struct {
 	Routes chi.Router
+
+	grpcServer  *grpc.Server
+	grpcAddress string
+	tlsConfig   *tls.Config
 }
 
 // NewServer creates new instance of server with routes
@@ -19,9 +34,92 @@ func NewServer(router chi.Router) *Server {
 	}
 }
 
-// Run starts the server
-func (s *Server) Run(port int) {
-	log.Infow("Server started", "port", port)
-	err := http.ListenAndServe(fmt.Sprintf(":%d", port), s.Routes)
-	log.Fatal(err)
+// WithGRPC makes server serve gRPC API on the given address, or on the same listeners as REST API if address is empty
+func (s *Server) WithGRPC(grpcServer *grpc.Server, address string) *Server {
+	s.grpcServer = grpcServer
+	s.grpcAddress = address
+	return s
+}
+
+// WithTLS makes server serve HTTPS with the given config
+func (s *Server) WithTLS(tlsConfig *tls.Config) *Server {
+	s.tlsConfig = tlsConfig
+	return s
+}
+
+// Run starts the server on the addresses until context is done, see Listen for the address format
+func (s *Server) Run(ctx context.Context, addresses ...string) error {
+	listeners := make([]net.Listener, len(addresses))
+	for i, address := range addresses {
+		lis, err := Listen(address)
+		if err != nil {
+			return err
+		}
+		listeners[i] = lis
+	}
+
+	return s.Serve(ctx, listeners...)
+}
+
+// Serve serves API on all listeners, it returns when serving on any of them fails or when context is done,
+// then requests being served are waited for within shutdown timeout
+func (s *Server) Serve(ctx context.Context, listeners ...net.Listener) error {
+	var handler http.Handler = s.Routes
+	errs := make(chan error, len(listeners)+1)
+
+	if s.grpcServer != nil {
+		if s.grpcAddress != "" {
+			lis, err := Listen(s.grpcAddress)
+			if err != nil {
+				return err
+			}
+			go func() {
+				log.Infow("gRPC server started", "address", lis.Addr().String())
+				errs <- s.grpcServer.Serve(lis)
+			}()
+			defer s.grpcServer.Stop()
+		} else {
+			// serve gRPC over cleartext HTTP/2 on the REST listeners
+			handler = h2c.NewHandler(grpcHandler(s.grpcServer, s.Routes), &http2.Server{})
+		}
+	}
+
+	srv := &http.Server{
+		Handler:   handler,
+		TLSConfig: s.tlsConfig,
+	}
+	defer srv.Close()
+
+	for _, lis := range listeners {
+		go func(lis net.Listener) {
+			log.Infow("Server started", "address", lis.Addr().String(), "tls", s.tlsConfig != nil)
+			if s.tlsConfig != nil {
+				// certificates are provided by TLSConfig
+				errs <- srv.ServeTLS(lis, "", "")
+				return
+			}
+			errs <- srv.Serve(lis)
+		}(lis)
+	}
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		log.Infow("Server is shutting down")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	}
+}
+
+// grpcHandler routes gRPC requests to grpcServer and everything else to other handler
+func grpcHandler(grpcServer *grpc.Server, other http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			grpcServer.ServeHTTP(w, r)
+			return
+		}
+		other.ServeHTTP(w, r)
+	})
 }
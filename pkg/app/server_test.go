@@ -0,0 +1,71 @@
+package app
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerServesAllListeners(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "prover.sock")
+
+	// socket file left by previous run is replaced
+	stale, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, stale.Close())
+
+	tcpLis, err := Listen("127.0.0.1:0")
+	require.NoError(t, err)
+	unixLis, err := Listen("unix:" + socketPath)
+	require.NoError(t, err)
+
+	handlers := Handlers{}
+	server := NewServer(handlers.Routes())
+	errs := make(chan error, 1)
+	go func() { errs <- server.Serve(context.Background(), tcpLis, unixLis) }()
+
+	resp, err := http.Get("http://" + tcpLis.Addr().String() + "/api/v1/status")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	unixClient := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+		},
+	}}
+	resp, err = unixClient.Get("http://prover/api/v1/status")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Serve returns when any listener fails
+	require.NoError(t, tcpLis.Close())
+	require.Error(t, <-errs)
+
+	// Serve returns when context is done
+	lis, err := Listen("127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { errs <- server.Serve(ctx, lis) }()
+	cancel()
+	require.NoError(t, <-errs)
+}
+
+func TestListenKeepsRegularFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "prover.sock")
+	require.NoError(t, os.WriteFile(filePath, []byte("data"), 0o600))
+
+	_, err := Listen("unix:" + filePath)
+	require.Error(t, err)
+
+	data, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	require.Equal(t, "data", string(data))
+}
@@ -0,0 +1,202 @@
+package circuits
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// archiveExtensions are extensions of archive files with circuits, longer extensions go first
+var archiveExtensions = []string{".tar.gz", ".tgz", ".tar", ".zip"}
+
+// archiveExtension returns extension of archive file, or empty string if it's not an archive
+func archiveExtension(name string) string {
+	for _, ext := range archiveExtensions {
+		if strings.HasSuffix(strings.ToLower(name), ext) {
+			return ext
+		}
+	}
+	return ""
+}
+
+// archivesDir is a directory of snapshots directory archives are extracted to
+const archivesDir = ".archives"
+
+// archiveIndex is a list of files in archive and directory it's extracted to, it's kept until the archive changes
+type archiveIndex struct {
+	fingerprint string
+	files       []string
+	dir         string
+}
+
+// circuitDirs returns directories of circuits in archive. Archive with files in its root is a single circuit
+// named after the archive, otherwise each top-level directory is a circuit.
+func (i *archiveIndex) circuitDirs() []string {
+	dirs := make(map[string]bool)
+	for _, f := range i.files {
+		dir := strings.SplitN(f, "/", 2)
+		if len(dir) == 1 {
+			return []string{"."}
+		}
+		dirs[dir[0]] = true
+	}
+
+	list := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		list = append(list, dir)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// extractArchive extracts regular files of the archive into directory of archivesPath named by SHA-256 hash
+// of the archive, so that circuits are read from the archive once. Tar archives are decompressed and hashed in
+// a single pass. Entries with paths escaping the archive are skipped.
+func extractArchive(archivePath, archivesPath string) (*archiveIndex, error) {
+	if err := os.MkdirAll(archivesPath, 0o755); err != nil {
+		return nil, errors.Wrap(err, "failed to create archives directory")
+	}
+	tmp, err := os.MkdirTemp(archivesPath, ".extract-")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create archive directory")
+	}
+	defer os.RemoveAll(tmp)
+
+	index := &archiveIndex{}
+	extracted := make(map[string]bool)
+	extract := func(name string, r io.Reader) error {
+		name = path.Clean(name)
+		if !fs.ValidPath(name) {
+			return nil
+		}
+		filePath := filepath.Join(tmp, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+			return errors.Wrap(err, "failed to create archive directory")
+		}
+		// the last entry of file wins like in tar
+		f, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+		if err != nil {
+			return errors.Wrapf(err, "failed to extract %s", name)
+		}
+		if _, err = io.Copy(f, r); err != nil {
+			_ = f.Close()
+			return errors.Wrapf(err, "failed to extract %s", name)
+		}
+		if err = f.Close(); err != nil {
+			return errors.Wrapf(err, "failed to extract %s", name)
+		}
+		if !extracted[name] {
+			extracted[name] = true
+			index.files = append(index.files, name)
+		}
+		return nil
+	}
+
+	var sum string
+	if archiveExtension(archivePath) == ".zip" {
+		sum, err = extractZip(archivePath, extract)
+	} else {
+		sum, err = extractTar(archivePath, extract)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	index.dir = filepath.Join(archivesPath, sum)
+	if _, err = os.Stat(index.dir); err == nil {
+		// archive with the same content is extracted already
+		return index, nil
+	}
+	if err = os.Rename(tmp, index.dir); err != nil {
+		return nil, errors.Wrap(err, "failed to extract archive")
+	}
+	return index, nil
+}
+
+// extractZip calls extract for each regular file of zip archive and returns hex encoded SHA-256 hash of the archive
+func extractZip(archivePath string, extract func(name string, r io.Reader) error) (string, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to open archive")
+	}
+	defer f.Close()
+	sha := sha256.New()
+	if _, err = io.Copy(sha, f); err != nil {
+		return "", errors.Wrap(err, "failed to read archive")
+	}
+
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to open archive")
+	}
+	defer zr.Close()
+	for _, zf := range zr.File {
+		if zf.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			return "", errors.Wrap(err, "failed to read archive")
+		}
+		err = extract(zf.Name, rc)
+		_ = rc.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(sha.Sum(nil)), nil
+}
+
+// extractTar calls extract for each regular file of tar archive, which can be gzip compressed, and returns
+// hex encoded SHA-256 hash of the archive
+func extractTar(archivePath string, extract func(name string, r io.Reader) error) (string, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to open archive")
+	}
+	defer f.Close()
+
+	sha := sha256.New()
+	var r io.Reader = io.TeeReader(f, sha)
+	if archiveExtension(archivePath) != ".tar" {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to read archive")
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", errors.Wrap(err, "failed to read archive")
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err = extract(hdr.Name, tr); err != nil {
+			return "", err
+		}
+	}
+	// padding after the last entry is hashed too
+	if _, err = io.Copy(sha, f); err != nil {
+		return "", errors.Wrap(err, "failed to read archive")
+	}
+	return hex.EncodeToString(sha.Sum(nil)), nil
+}
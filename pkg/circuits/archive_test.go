@@ -0,0 +1,142 @@
+package circuits
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iden3/prover-server/pkg/app/configs"
+	"github.com/iden3/prover-server/pkg/circuits/circuitstest"
+	"github.com/iden3/prover-server/pkg/proof"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryLoadsArchives(t *testing.T) {
+	basePath := t.TempDir()
+	circuitstest.WriteCircuit(t, basePath, "auth")
+	// archive with several circuits in top-level directories
+	writeTarGz(t, filepath.Join(basePath, "release.tar.gz"), map[string][]byte{
+		"authV2/" + proof.WasmFile:            circuitstest.Artifacts[proof.WasmFile],
+		"authV2/" + proof.ZKeyFile:            circuitstest.Artifacts[proof.ZKeyFile],
+		"authV2/" + proof.VerificationKeyFile: circuitstest.Artifacts[proof.VerificationKeyFile],
+		"authV2/" + ManifestFile:              []byte("name: auth\nversion: 2.0.0\n"),
+		"broken/" + proof.WasmFile:            circuitstest.Artifacts[proof.WasmFile],
+	})
+	// archive with a single circuit
+	writeZip(t, filepath.Join(basePath, "sig.zip"), map[string][]byte{
+		proof.WasmFile:            circuitstest.Artifacts[proof.WasmFile],
+		proof.ZKeyFile:            circuitstest.Artifacts[proof.ZKeyFile],
+		proof.VerificationKeyFile: circuitstest.Artifacts[proof.VerificationKeyFile],
+		"selftest_inputs.json":    []byte("{}"),
+	})
+	require.NoError(t, os.WriteFile(filepath.Join(basePath, "corrupted.zip"), []byte("zip"), 0o600))
+
+	r, err := NewRegistry(configs.ProverConfig{CircuitsBasePath: basePath})
+	require.NoError(t, err)
+	defer r.Close()
+
+	c, err := r.Get("auth@latest")
+	require.NoError(t, err)
+	require.Equal(t, "authV2", c.Dir)
+	require.Equal(t, "release.tar.gz", c.Archive)
+	require.Equal(t, circuitstest.Artifacts[proof.ZKeyFile], readArtifacts(t, r, c).ZKey)
+
+	c, err = r.Get("sig")
+	require.NoError(t, err)
+	require.Equal(t, "sig.zip", c.Archive)
+	inputs, err := c.ReadFile("selftest_inputs.json")
+	require.NoError(t, err)
+	require.Equal(t, "{}", string(inputs))
+
+	require.Len(t, r.List(), 3)
+	require.Contains(t, r.LoadErrors(), "release.tar.gz/broken")
+	require.Contains(t, r.LoadErrors(), "corrupted.zip")
+
+	// changed archive is reloaded
+	loaded, release, err := r.Acquire(context.Background(), "auth@2.0.0")
+	require.NoError(t, err)
+	defer release()
+	zkey := append([]byte("zkey"), 2, 0, 0, 0)
+	writeTarGz(t, filepath.Join(basePath, "release.tar.gz"), map[string][]byte{
+		"authV2/" + proof.WasmFile:            circuitstest.Artifacts[proof.WasmFile],
+		"authV2/" + proof.ZKeyFile:            zkey,
+		"authV2/" + proof.VerificationKeyFile: circuitstest.Artifacts[proof.VerificationKeyFile],
+		"authV2/" + ManifestFile:              []byte("name: auth\nversion: 2.0.0\n"),
+	})
+	require.NoError(t, os.Chtimes(filepath.Join(basePath, "release.tar.gz"), time.Now(), time.Now().Add(time.Second)))
+	require.NoError(t, r.Reload())
+	c, err = r.Get("auth@2.0.0")
+	require.NoError(t, err)
+	require.Equal(t, zkey, readArtifacts(t, r, c).ZKey)
+	require.Equal(t, circuitstest.Artifacts[proof.ZKeyFile], readArtifacts(t, r, loaded).ZKey)
+	require.NotContains(t, r.LoadErrors(), "release.tar.gz/broken")
+
+	// archives are extracted once on load, extracted files are removed with archives
+	archivesPath := filepath.Join(c.Location.Path, archivesDir)
+	extracted, err := os.ReadDir(archivesPath)
+	require.NoError(t, err)
+	require.Len(t, extracted, 2)
+	require.NoError(t, os.Remove(filepath.Join(basePath, "sig.zip")))
+	c, err = r.Get("sig")
+	require.NoError(t, err)
+	inputs, err = c.ReadFile("selftest_inputs.json")
+	require.NoError(t, err)
+	require.Equal(t, "{}", string(inputs))
+	require.NoError(t, r.Reload())
+	extracted, err = os.ReadDir(archivesPath)
+	require.NoError(t, err)
+	require.Len(t, extracted, 1)
+}
+
+func TestRegistryCircuitsPathIsArchive(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "circuits.zip")
+	writeZip(t, archivePath, map[string][]byte{
+		"auth/" + proof.WasmFile:            circuitstest.Artifacts[proof.WasmFile],
+		"auth/" + proof.ZKeyFile:            circuitstest.Artifacts[proof.ZKeyFile],
+		"auth/" + proof.VerificationKeyFile: circuitstest.Artifacts[proof.VerificationKeyFile],
+	})
+
+	r, err := NewRegistry(configs.ProverConfig{CircuitsBasePath: archivePath})
+	require.NoError(t, err)
+	defer r.Close()
+	_, err = r.Get("auth")
+	require.NoError(t, err)
+}
+
+func writeTarGz(t *testing.T, archivePath string, files map[string][]byte) {
+	t.Helper()
+
+	f, err := os.Create(archivePath)
+	require.NoError(t, err)
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: int64(len(content))}))
+		_, err = tw.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+}
+
+func writeZip(t *testing.T, archivePath string, files map[string][]byte) {
+	t.Helper()
+
+	f, err := os.Create(archivePath)
+	require.NoError(t, err)
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+}
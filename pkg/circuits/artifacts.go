@@ -0,0 +1,140 @@
+package circuits
+
+import (
+	"container/list"
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/iden3/prover-server/pkg/inflight"
+	"github.com/iden3/prover-server/pkg/proof"
+	"github.com/pkg/errors"
+)
+
+// Location is where snapshots of artifact files of a loaded circuit are, it's passed to worker processes which
+// read artifacts themselves
+type Location struct {
+	// Path is a path of snapshots directory
+	Path string
+	// Files are names of snapshots of artifacts
+	Files proof.ArtifactFiles
+	// Hashes are SHA-256 hashes of artifacts the circuit was loaded with
+	Hashes Hashes
+}
+
+// ReadArtifacts reads artifacts of the circuit for the prover, they're checked to be the files the circuit
+// was loaded with. Zkey file on disk isn't read for proof.FileProver, its path is passed to the prover instead.
+func (l Location) ReadArtifacts(p proof.Prover) (*proof.Artifacts, error) {
+	files, hashes := l.Files, l.Hashes
+	zkeyPath := l.zkeyPath(p)
+	if zkeyPath != "" {
+		files.ZKey, hashes.ZKey = "", ""
+	}
+	artifacts, err := proof.ReadArtifacts(os.DirFS(l.Path), files)
+	if err != nil {
+		return nil, err
+	}
+	if err = VerifyHashes(artifacts, hashes); err != nil {
+		return nil, errors.Wrap(err, "snapshot of circuit files is corrupted")
+	}
+	artifacts.ZKeyPath = zkeyPath
+	return artifacts, nil
+}
+
+// zkeyPath returns path of zkey file if the prover reads it itself
+func (l Location) zkeyPath(p proof.Prover) string {
+	if _, ok := p.(proof.FileProver); !ok {
+		return ""
+	}
+	return filepath.Join(l.Path, filepath.FromSlash(l.Files.ZKey))
+}
+
+// ArtifactCache keeps artifacts of recently used circuits while their total size fits into the limit,
+// the least recently used are evicted. Artifacts larger than the limit are read for every proof.
+type ArtifactCache struct {
+	maxSize  int64
+	inflight *inflight.Group
+
+	mu      sync.Mutex
+	size    int64
+	entries map[string]*list.Element
+	// lru is a list of cache entries from the most recently used
+	lru *list.List
+}
+
+// cacheEntry is artifacts cached by hashes of their files
+type cacheEntry struct {
+	key       string
+	artifacts *proof.Artifacts
+	size      int64
+}
+
+// NewArtifactCache creates cache of artifacts up to maxSize bytes, 0 disables caching
+func NewArtifactCache(maxSize int64) *ArtifactCache {
+	return &ArtifactCache{
+		maxSize:  maxSize,
+		inflight: inflight.NewGroup(),
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// Get returns cached artifacts of the location for the prover or reads them, concurrent reads of the same
+// artifacts are shared
+func (c *ArtifactCache) Get(ctx context.Context, loc Location, p proof.Prover) (*proof.Artifacts, error) {
+	key := loc.Hashes.Wasm + "/" + loc.Hashes.ZKey + "/" + loc.Hashes.VerificationKey
+	if zkeyPath := loc.zkeyPath(p); zkeyPath != "" {
+		key = loc.Hashes.Wasm + "/" + zkeyPath + "/" + loc.Hashes.VerificationKey
+	}
+	if artifacts := c.get(key); artifacts != nil {
+		return artifacts, nil
+	}
+
+	res, _, err := c.inflight.Do(ctx, key, func(context.Context) (interface{}, error) {
+		artifacts, err := loc.ReadArtifacts(p)
+		if err != nil {
+			return nil, err
+		}
+		c.add(key, artifacts)
+		return artifacts, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*proof.Artifacts), nil
+}
+
+func (c *ArtifactCache) get(key string) *proof.Artifacts {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	c.lru.MoveToFront(e)
+	return e.Value.(*cacheEntry).artifacts
+}
+
+// add caches artifacts, the least recently used artifacts are evicted to keep size within the limit
+func (c *ArtifactCache) add(key string, artifacts *proof.Artifacts) {
+	size := int64(len(artifacts.Wasm) + len(artifacts.ZKey) + len(artifacts.VerificationKey))
+	if size > c.maxSize {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[key]; ok {
+		return
+	}
+	for c.size+size > c.maxSize {
+		oldest := c.lru.Remove(c.lru.Back()).(*cacheEntry)
+		delete(c.entries, oldest.key)
+		c.size -= oldest.size
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, artifacts: artifacts, size: size})
+	c.size += size
+}
@@ -0,0 +1,89 @@
+package circuits
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/iden3/prover-server/pkg/app/configs"
+	"github.com/iden3/prover-server/pkg/circuits/circuitstest"
+	"github.com/iden3/prover-server/pkg/proof"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArtifactCache(t *testing.T) {
+	basePath := t.TempDir()
+	authPath := circuitstest.WriteCircuit(t, basePath, "auth")
+	sigPath := circuitstest.WriteCircuit(t, basePath, "sig")
+	require.NoError(t, os.WriteFile(filepath.Join(sigPath, proof.ZKeyFile), []byte("zkey\x02\x00\x00\x00"), 0o600))
+
+	r, err := NewRegistry(configs.ProverConfig{CircuitsBasePath: basePath})
+	require.NoError(t, err)
+	defer r.Close()
+	auth, err := r.Get("auth")
+	require.NoError(t, err)
+	sig, err := r.Get("sig")
+	require.NoError(t, err)
+
+	var size int
+	for _, content := range circuitstest.Artifacts {
+		size += len(content)
+	}
+	ctx := context.Background()
+	cache := NewArtifactCache(int64(size))
+	artifacts, err := cache.Get(ctx, auth.Location, auth.Prover)
+	require.NoError(t, err)
+	require.Equal(t, circuitstest.Artifacts[proof.WasmFile], artifacts.Wasm)
+
+	// cached artifacts aren't read again
+	require.NoError(t, os.WriteFile(filepath.Join(authPath, proof.ZKeyFile), []byte("zkey\x03\x00\x00\x00"), 0o600))
+	cached, err := cache.Get(ctx, auth.Location, auth.Prover)
+	require.NoError(t, err)
+	require.Same(t, artifacts, cached)
+
+	// the least recently used artifacts are evicted, they're read again from snapshots the circuit was loaded with
+	_, err = cache.Get(ctx, sig.Location, sig.Prover)
+	require.NoError(t, err)
+	artifacts, err = cache.Get(ctx, auth.Location, auth.Prover)
+	require.NoError(t, err)
+	require.Equal(t, circuitstest.Artifacts[proof.ZKeyFile], artifacts.ZKey)
+
+	// corrupted snapshots are rejected
+	_, err = cache.Get(ctx, sig.Location, sig.Prover)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(auth.Location.Path, auth.Location.Files.ZKey),
+		[]byte("zkey\x03\x00\x00\x00"), 0o600))
+	_, err = cache.Get(ctx, auth.Location, auth.Prover)
+	require.ErrorContains(t, err, "snapshot of circuit files is corrupted")
+
+	// artifacts are read for every proof without cache
+	cache = NewArtifactCache(0)
+	artifacts, err = cache.Get(ctx, sig.Location, sig.Prover)
+	require.NoError(t, err)
+	cached, err = cache.Get(ctx, sig.Location, sig.Prover)
+	require.NoError(t, err)
+	require.NotSame(t, artifacts, cached)
+}
+
+func TestArtifactsOfFileProver(t *testing.T) {
+	basePath := t.TempDir()
+	circuitstest.WriteCircuit(t, basePath, "auth")
+	r, err := NewRegistry(configs.ProverConfig{
+		CircuitsBasePath: basePath,
+		Backend:          configs.ProverBackendConfig{Type: proof.BackendBinary, Path: "/usr/local/bin/prover"},
+	})
+	require.NoError(t, err)
+	defer r.Close()
+	c, err := r.Get("auth")
+	require.NoError(t, err)
+
+	// zkey isn't read, prover reads its snapshot from disk
+	artifacts, err := r.Artifacts(context.Background(), c)
+	require.NoError(t, err)
+	require.Nil(t, artifacts.ZKey)
+	zkey, err := os.ReadFile(artifacts.ZKeyPath)
+	require.NoError(t, err)
+	require.Equal(t, circuitstest.Artifacts[proof.ZKeyFile], zkey)
+	require.Equal(t, circuitstest.Artifacts[proof.WasmFile], artifacts.Wasm)
+}
@@ -0,0 +1,52 @@
+package circuitstest
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/iden3/prover-server/pkg/proof"
+	"github.com/stretchr/testify/require"
+)
+
+// Artifacts are placeholders of circuit files which pass format validation, but can't generate proofs
+var Artifacts = map[string][]byte{
+	proof.WasmFile:            []byte("\x00asm\x01\x00\x00\x00"),
+	proof.ZKeyFile:            []byte("zkey\x01\x00\x00\x00"),
+	proof.VerificationKeyFile: []byte(`{"protocol":"groth16","curve":"bn128","nPublic":0}`),
+}
+
+// WriteCircuit creates circuit directory with placeholder artifacts and returns its path
+func WriteCircuit(t testing.TB, basePath, name string) string {
+	t.Helper()
+
+	circuitPath := filepath.Join(basePath, name)
+	require.NoError(t, os.MkdirAll(circuitPath, 0o755))
+	for file, content := range Artifacts {
+		require.NoError(t, os.WriteFile(filepath.Join(circuitPath, file), content, 0o600))
+	}
+	return circuitPath
+}
+
+// MultiplierInputs are inputs of the multiplier circuit, its public signals are out = x*y and sum = x+y
+var MultiplierInputs = proof.ZKInputs{"x": 3, "y": 5}
+
+// WriteMultiplier creates circuit directory with artifacts of testdata/multiplier of proof package and returns
+// its path. Proofs of the circuit are generated with gnark prover and wazero witness calculator, which don't
+// require native libraries.
+func WriteMultiplier(t testing.TB, basePath, name string) string {
+	t.Helper()
+
+	_, file, _, ok := runtime.Caller(0)
+	require.True(t, ok)
+	testdata := filepath.Join(filepath.Dir(file), "..", "..", "proof", "testdata", "multiplier")
+	circuitPath := filepath.Join(basePath, name)
+	require.NoError(t, os.MkdirAll(circuitPath, 0o755))
+	for _, f := range []string{proof.WasmFile, proof.ZKeyFile, proof.VerificationKeyFile} {
+		content, err := os.ReadFile(filepath.Join(testdata, f))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(circuitPath, f), content, 0o600))
+	}
+	return circuitPath
+}
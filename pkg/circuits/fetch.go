@@ -0,0 +1,193 @@
+package circuits
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/iden3/prover-server/pkg/app/configs"
+	"github.com/iden3/prover-server/pkg/circuits/remote"
+	"github.com/iden3/prover-server/pkg/inflight"
+	"github.com/iden3/prover-server/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// fetcher downloads circuit directories from remote store into cache directory
+type fetcher struct {
+	store     remote.Store
+	cachePath string
+	// circuits are names of circuit directories available in the store
+	circuits []string
+	// inflight deduplicates concurrent fetches of the same circuit
+	inflight *inflight.Group
+}
+
+func newFetcher(config configs.RemoteConfig) (*fetcher, error) {
+	store, err := remote.NewStore(config)
+	if err != nil {
+		return nil, err
+	}
+	if config.CachePath == "" {
+		return nil, errors.New("remote store requires cache path")
+	}
+	if err = os.MkdirAll(config.CachePath, 0o755); err != nil {
+		return nil, errors.Wrap(err, "failed to create circuits cache")
+	}
+	for _, dir := range config.Circuits {
+		if err = validateName(dir); err != nil {
+			return nil, errors.Wrapf(err, "illegal remote circuit %q", dir)
+		}
+	}
+	return &fetcher{
+		store:     store,
+		cachePath: config.CachePath,
+		circuits:  config.Circuits,
+		inflight:  inflight.NewGroup(),
+	}, nil
+}
+
+// Resolve returns enabled circuit by reference like Get. Circuit of remote store which isn't fetched yet
+// is referenced by its directory name and fetched into cache by the first request, ErrUnavailable is returned
+// if fetch fails.
+func (r *Registry) Resolve(ctx context.Context, ref string) (Circuit, error) {
+	return r.fetchAndGet(ctx, ref, false)
+}
+
+// Acquire resolves circuit like Resolve and keeps snapshots of its artifacts until release is called,
+// so the circuit can be used for proof even if it's reloaded meanwhile
+func (r *Registry) Acquire(ctx context.Context, ref string) (Circuit, func(), error) {
+	c, err := r.fetchAndGet(ctx, ref, true)
+	if err != nil {
+		return Circuit{}, nil, err
+	}
+	var once sync.Once
+	return c, func() {
+		once.Do(func() { r.snapshots.release(c.Location.Files.List()...) })
+	}, nil
+}
+
+// fetchAndGet returns circuit by reference, fetching it from remote store if needed
+func (r *Registry) fetchAndGet(ctx context.Context, ref string, pin bool) (Circuit, error) {
+	c, err := r.get(ref, pin)
+	if r.remote == nil || !errors.Is(err, ErrNotFound) {
+		return c, err
+	}
+
+	if target, ok := r.aliases[ref]; ok {
+		ref = target
+	}
+	name, _ := splitRef(ref)
+	for _, dir := range r.remote.circuits {
+		if dir == name {
+			if err = r.Fetch(ctx, dir); err != nil {
+				log.WithContext(ctx).Errorw("failed to fetch circuit", "circuit", dir, "error", err)
+				return Circuit{}, ErrUnavailable
+			}
+			return r.get(ref, pin)
+		}
+	}
+	return Circuit{}, ErrNotFound
+}
+
+// Prefetch fetches all circuits of remote store which aren't cached yet, errors are logged
+func (r *Registry) Prefetch(ctx context.Context) {
+	if r.remote == nil {
+		return
+	}
+	for _, dir := range r.remote.circuits {
+		if err := r.Fetch(ctx, dir); err != nil {
+			log.Errorw("failed to fetch circuit", "circuit", dir, "error", err)
+		}
+	}
+}
+
+// Fetch downloads circuit directory from remote store into cache unless it's cached already and reloads
+// circuits. Downloaded circuit is cached only if it's valid and matches pinned hashes.
+func (r *Registry) Fetch(ctx context.Context, dir string) error {
+	if r.remote == nil {
+		return ErrNotFound
+	}
+	_, _, err := r.remote.inflight.Do(ctx, dir, func(ctx context.Context) (interface{}, error) {
+		return nil, r.fetch(ctx, dir)
+	})
+	return err
+}
+
+func (r *Registry) fetch(ctx context.Context, dir string) error {
+	target := filepath.Join(r.remote.cachePath, dir)
+	if _, err := os.Stat(target); err == nil {
+		return r.Reload()
+	}
+
+	// hidden directory isn't loaded by reload until it's complete
+	tmp, err := os.MkdirTemp(r.remote.cachePath, "."+dir+"-")
+	if err != nil {
+		return errors.Wrap(err, "failed to create circuit directory in cache")
+	}
+	defer os.RemoveAll(tmp)
+
+	log.Infow("Fetching circuit", "circuit", dir)
+	if err = r.download(ctx, dir, ManifestFile, tmp); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	src := dirSource(r.remote.cachePath, filepath.Base(tmp))
+	src.dir = dir
+	manifest, err := ReadManifest(src.fsys)
+	if err != nil {
+		return err
+	}
+	files, err := r.artifactFiles(dir, manifest)
+	if err != nil {
+		return err
+	}
+	for _, f := range files.List() {
+		if err = r.download(ctx, dir, f, tmp); err != nil {
+			return err
+		}
+	}
+	c, err := r.load(src, manifest, files, "")
+	if err != nil {
+		return errors.Wrap(err, "fetched circuit is invalid")
+	}
+
+	if err = os.Rename(tmp, target); err != nil {
+		r.snapshots.release(c.Location.Files.List()...)
+		return errors.Wrap(err, "failed to move circuit into cache")
+	}
+	log.Infow("Circuit fetched", "circuit", dir)
+
+	// validated circuit is moved along with its files, so reload doesn't read them again
+	moved := dirSource(r.remote.cachePath, dir)
+	if c.fingerprint, err = fingerprint(moved.fsys, files); err != nil {
+		r.snapshots.release(c.Location.Files.List()...)
+		return err
+	}
+	c.Path, c.fsys = moved.path, moved.fsys
+	return r.reload(c)
+}
+
+// download saves file of circuit directory from remote store into directory
+func (r *Registry) download(ctx context.Context, dir, file, toPath string) error {
+	rc, err := r.remote.store.Open(ctx, dir, file)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	filePath := filepath.Join(toPath, filepath.FromSlash(file))
+	if err = os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return errors.Wrap(err, "failed to create circuit directory in cache")
+	}
+	f, err := os.Create(filePath)
+	if err != nil {
+		return errors.Wrap(err, "failed to create circuit file in cache")
+	}
+	if _, err = io.Copy(f, rc); err != nil {
+		_ = f.Close()
+		return errors.Wrapf(err, "failed to download %s", file)
+	}
+	return errors.Wrapf(f.Close(), "failed to write %s", file)
+}
@@ -0,0 +1,81 @@
+package circuits
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/iden3/prover-server/pkg/app/configs"
+	"github.com/iden3/prover-server/pkg/circuits/circuitstest"
+	"github.com/iden3/prover-server/pkg/proof"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryFetchesRemoteCircuits(t *testing.T) {
+	storePath := t.TempDir()
+	circuitstest.WriteCircuit(t, storePath, "authV2")
+	writeManifest(t, filepath.Join(storePath, "authV2"), "name: auth\nversion: 2.0.0\n")
+	circuitstest.WriteCircuit(t, storePath, "tampered")
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		http.FileServer(http.Dir(storePath)).ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	zkeyHash := sha256.Sum256(circuitstest.Artifacts[proof.ZKeyFile])
+	cachePath := filepath.Join(t.TempDir(), "cache")
+	config := configs.ProverConfig{
+		CircuitsBasePath: t.TempDir(),
+		PinnedHashes: []configs.PinnedHashesConfig{
+			{Circuit: "auth@2.0.0", ZKey: hex.EncodeToString(zkeyHash[:])},
+			{Circuit: "tampered", ZKey: hex.EncodeToString(make([]byte, sha256.Size))},
+		},
+		Remote: configs.RemoteConfig{
+			Type:      "http",
+			URL:       srv.URL,
+			Circuits:  []string{"authV2", "tampered", "missing"},
+			CachePath: cachePath,
+		},
+	}
+	r, err := NewRegistry(config)
+	require.NoError(t, err)
+	defer r.Close()
+	require.Empty(t, r.List())
+
+	_, err = r.Get("authV2")
+	require.ErrorIs(t, err, ErrNotFound)
+	c, err := r.Resolve(context.Background(), "authV2")
+	require.NoError(t, err)
+	require.Equal(t, "auth@2.0.0", c.ID())
+	require.Equal(t, filepath.Join(cachePath, "authV2"), c.Path)
+	require.Equal(t, circuitstest.Artifacts[proof.ZKeyFile], readArtifacts(t, r, c).ZKey)
+	_, err = r.Get("auth@latest")
+	require.NoError(t, err)
+
+	// invalid circuits aren't cached
+	_, err = r.Resolve(context.Background(), "tampered")
+	require.ErrorIs(t, err, ErrUnavailable)
+	_, err = r.Resolve(context.Background(), "missing")
+	require.ErrorIs(t, err, ErrUnavailable)
+	_, err = r.Resolve(context.Background(), "unknown")
+	require.ErrorIs(t, err, ErrNotFound)
+	entries, err := os.ReadDir(cachePath)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	// cached circuits are loaded without fetching
+	fetched := atomic.LoadInt32(&requests)
+	r, err = NewRegistry(config)
+	require.NoError(t, err)
+	defer r.Close()
+	_, err = r.Resolve(context.Background(), "auth@2.0.0")
+	require.NoError(t, err)
+	require.Equal(t, fetched, atomic.LoadInt32(&requests))
+}
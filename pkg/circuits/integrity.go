@@ -0,0 +1,161 @@
+package circuits
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/iden3/prover-server/pkg/proof"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
+)
+
+// Hashes are pinned hashes of circuit artifacts, each is hex encoded "sha256:<hash>" or "blake2b:<hash>"
+// (BLAKE2b-256), hash without algorithm prefix is SHA-256. Empty hash isn't checked.
+type Hashes struct {
+	Wasm            string `yaml:"wasm"`
+	ZKey            string `yaml:"zkey"`
+	VerificationKey string `yaml:"verificationKey"`
+}
+
+// VerifyHashes checks that artifacts match pinned hashes
+func VerifyHashes(artifacts *proof.Artifacts, hashes Hashes) error {
+	for _, a := range []struct {
+		kind    string
+		content []byte
+		hash    string
+	}{
+		{"wasm", artifacts.Wasm, hashes.Wasm},
+		{"zkey", artifacts.ZKey, hashes.ZKey},
+		{"verification key", artifacts.VerificationKey, hashes.VerificationKey},
+	} {
+		if a.hash == "" {
+			continue
+		}
+		if err := verifyHash(a.content, a.hash); err != nil {
+			return errors.Wrapf(err, "integrity check of %s failed", a.kind)
+		}
+	}
+	return nil
+}
+
+// fileDigests are SHA-256 hashes and sizes of artifact files and names of their snapshots
+type fileDigests struct {
+	hashes    Hashes
+	wasm      int64
+	zkey      int64
+	snapshots proof.ArtifactFiles
+}
+
+// hashFiles reads artifact files once, it checks them against each set of pinned hashes and returns
+// their SHA-256 hashes and sizes. Mismatch of a set is reported with its description. Files are copied
+// into snapshots while they're read, returned snapshots are referred to until they're released.
+func hashFiles(fsys fs.FS, files proof.ArtifactFiles, pinned []Hashes, descriptions []string,
+	snaps *snapshots) (d fileDigests, err error) {
+
+	var vkeySize int64
+	defer func() {
+		if err != nil {
+			snaps.release(d.snapshots.List()...)
+			d = fileDigests{}
+		}
+	}()
+	for _, a := range []struct {
+		kind     string
+		name     string
+		sum      *string
+		size     *int64
+		snapshot *string
+		hash     func(Hashes) string
+	}{
+		{"wasm", files.Wasm, &d.hashes.Wasm, &d.wasm, &d.snapshots.Wasm, func(h Hashes) string { return h.Wasm }},
+		{"zkey", files.ZKey, &d.hashes.ZKey, &d.zkey, &d.snapshots.ZKey, func(h Hashes) string { return h.ZKey }},
+		{"verification key", files.VerificationKey, &d.hashes.VerificationKey, &vkeySize, &d.snapshots.VerificationKey,
+			func(h Hashes) string { return h.VerificationKey }},
+	} {
+		sha := sha256.New()
+		writers := []io.Writer{sha}
+		hashers := make([]hash.Hash, len(pinned))
+		expected := make([][]byte, len(pinned))
+		for i, h := range pinned {
+			if h := a.hash(h); h != "" {
+				if hashers[i], expected[i], err = parseHash(h); err != nil {
+					return d, errors.Wrap(err, descriptions[i])
+				}
+				writers = append(writers, hashers[i])
+			}
+		}
+
+		var tmpPath string
+		if tmpPath, *a.size, err = snaps.write(fsys, a.name, writers...); err != nil {
+			return d, err
+		}
+		for i, hasher := range hashers {
+			if hasher != nil && subtle.ConstantTimeCompare(hasher.Sum(nil), expected[i]) != 1 {
+				_ = os.Remove(tmpPath)
+				return d, errors.Errorf("%s: integrity check of %s failed: hash mismatch", descriptions[i], a.kind)
+			}
+		}
+		sum := hex.EncodeToString(sha.Sum(nil))
+		if err = snaps.add(tmpPath, sum); err != nil {
+			return d, err
+		}
+		*a.sum, *a.snapshot = "sha256:"+sum, sum
+	}
+	return d, nil
+}
+
+// ValidateHashes checks that hashes are well-formed, so typos in pinned hashes are reported as such
+func ValidateHashes(hashes Hashes) error {
+	for _, h := range []string{hashes.Wasm, hashes.ZKey, hashes.VerificationKey} {
+		if h == "" {
+			continue
+		}
+		if _, _, err := parseHash(h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func verifyHash(content []byte, pinned string) error {
+	hasher, expected, err := parseHash(pinned)
+	if err != nil {
+		return err
+	}
+	hasher.Write(content)
+	if subtle.ConstantTimeCompare(hasher.Sum(nil), expected) != 1 {
+		return errors.New("hash mismatch")
+	}
+	return nil
+}
+
+// parseHash returns hasher of the hash algorithm and decoded hash
+func parseHash(pinned string) (hash.Hash, []byte, error) {
+	algorithm, encoded := "sha256", pinned
+	if i := strings.Index(pinned, ":"); i >= 0 {
+		algorithm, encoded = strings.ToLower(pinned[:i]), pinned[i+1:]
+	}
+
+	var hasher hash.Hash
+	switch algorithm {
+	case "sha256":
+		hasher = sha256.New()
+	case "blake2b":
+		// error is returned only for invalid key
+		hasher, _ = blake2b.New256(nil)
+	default:
+		return nil, nil, errors.Errorf("unsupported hash algorithm %q", algorithm)
+	}
+
+	expected, err := hex.DecodeString(encoded)
+	if err != nil || len(expected) != hasher.Size() {
+		return nil, nil, errors.Errorf("malformed %s hash %q", algorithm, encoded)
+	}
+	return hasher, expected, nil
+}
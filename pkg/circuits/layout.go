@@ -0,0 +1,72 @@
+package circuits
+
+import (
+	"io/fs"
+	"strings"
+
+	"github.com/iden3/prover-server/pkg/app/configs"
+	"github.com/iden3/prover-server/pkg/proof"
+	"github.com/pkg/errors"
+)
+
+// Placeholders of layout templates
+const (
+	// NamePlaceholder is replaced with circuit name from manifest or directory name
+	NamePlaceholder = "{name}"
+	// DirPlaceholder is replaced with circuit directory name
+	DirPlaceholder = "{dir}"
+)
+
+// Layout is a template of slash separated artifact file paths relative to circuit directory, e.g. "{name}_js/{name}.wasm".
+// Empty path is taken from the layout with lower priority.
+type Layout struct {
+	Wasm            string `yaml:"wasm"`
+	ZKey            string `yaml:"zkey"`
+	VerificationKey string `yaml:"verificationKey"`
+}
+
+var defaultLayout = Layout{
+	Wasm:            proof.WasmFile,
+	ZKey:            proof.ZKeyFile,
+	VerificationKey: proof.VerificationKeyFile,
+}
+
+func layoutFromConfig(c configs.LayoutConfig) Layout {
+	return Layout{Wasm: c.Wasm, ZKey: c.ZKey, VerificationKey: c.VerificationKey}
+}
+
+// override returns layout with empty paths taken from l
+func (l Layout) override(o Layout) Layout {
+	if o.Wasm != "" {
+		l.Wasm = o.Wasm
+	}
+	if o.ZKey != "" {
+		l.ZKey = o.ZKey
+	}
+	if o.VerificationKey != "" {
+		l.VerificationKey = o.VerificationKey
+	}
+	return l
+}
+
+// files expands placeholders and checks that files are inside circuit directory
+func (l Layout) files(name, dir string) (proof.ArtifactFiles, error) {
+	replacer := strings.NewReplacer(NamePlaceholder, name, DirPlaceholder, dir)
+	files := proof.ArtifactFiles{
+		Wasm:            replacer.Replace(l.Wasm),
+		ZKey:            replacer.Replace(l.ZKey),
+		VerificationKey: replacer.Replace(l.VerificationKey),
+	}
+	for _, f := range files.List() {
+		if !fs.ValidPath(f) || f == "." {
+			return proof.ArtifactFiles{}, errors.Errorf("illegal artifact path %q", f)
+		}
+	}
+	return files, nil
+}
+
+// validateLayout checks that layout paths stay inside circuit directory whatever circuit name is
+func validateLayout(l Layout) error {
+	_, err := defaultLayout.override(l).files("circuit", "circuit")
+	return err
+}
@@ -0,0 +1,110 @@
+package circuits
+
+import (
+	"io/fs"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// ManifestFile is an optional file in circuit directory describing the circuit
+const ManifestFile = "manifest.yaml"
+
+// SelfTestInputsFile is an optional file in circuit directory with inputs used to generate test proof
+const SelfTestInputsFile = "selftest_inputs.json"
+
+// LatestVersion refers to the highest version of the circuit, unless it's overridden by alias
+const LatestVersion = "latest"
+
+// Manifest describes circuit, name and version identify the circuit instead of directory name
+type Manifest struct {
+	Name          string   `yaml:"name"`
+	Version       string   `yaml:"version"`
+	Description   string   `yaml:"description"`
+	PublicSignals []string `yaml:"publicSignals"`
+	// Hashes are pinned hashes of artifacts, circuit isn't loaded if its artifacts don't match them
+	Hashes Hashes `yaml:"hashes"`
+	// Files override paths of artifacts from config
+	Files Layout `yaml:"files"`
+	// MemoryMB is measured peak memory of proof generation, it's overridden by config
+	MemoryMB int64 `yaml:"memoryMB"`
+}
+
+// ReadManifest reads manifest from file system of circuit, nil is returned if there is no manifest
+func ReadManifest(fsys fs.FS) (*Manifest, error) {
+	b, err := fs.ReadFile(fsys, ManifestFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read manifest")
+	}
+
+	var m Manifest
+	if err = yaml.UnmarshalStrict(b, &m); err != nil {
+		return nil, errors.Wrap(err, "failed to parse manifest")
+	}
+	if m.Name != "" {
+		if err = validateName(m.Name); err != nil || strings.Contains(m.Name, "@") {
+			return nil, errors.Errorf("illegal circuit name %q in manifest", m.Name)
+		}
+	}
+	if strings.ContainsAny(m.Version, "@/\\") || m.Version == LatestVersion {
+		return nil, errors.Errorf("illegal circuit version %q in manifest", m.Version)
+	}
+	if err = ValidateHashes(m.Hashes); err != nil {
+		return nil, errors.Wrap(err, "illegal hashes in manifest")
+	}
+	if m.MemoryMB < 0 {
+		return nil, errors.New("illegal memoryMB in manifest")
+	}
+	if err = validateLayout(m.Files); err != nil {
+		return nil, errors.Wrap(err, "illegal files in manifest")
+	}
+	return &m, nil
+}
+
+// circuitID returns reference of circuit version: name@version, or name if version is empty
+func circuitID(name, version string) string {
+	if version == "" {
+		return name
+	}
+	return name + "@" + version
+}
+
+// splitRef splits circuit reference into name and version
+func splitRef(ref string) (name, version string) {
+	if i := strings.LastIndex(ref, "@"); i >= 0 {
+		return ref[:i], ref[i+1:]
+	}
+	return ref, ""
+}
+
+// compareVersions compares dot separated versions numerically where possible, "v" prefix is ignored
+func compareVersions(a, b string) int {
+	pa := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	pb := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var sa, sb string
+		if i < len(pa) {
+			sa = pa[i]
+		}
+		if i < len(pb) {
+			sb = pb[i]
+		}
+		na, errA := strconv.Atoi(sa)
+		nb, errB := strconv.Atoi(sb)
+		switch {
+		case errA == nil && errB == nil && na != nb:
+			if na < nb {
+				return -1
+			}
+			return 1
+		case (errA != nil || errB != nil) && sa != sb:
+			return strings.Compare(sa, sb)
+		}
+	}
+	return 0
+}
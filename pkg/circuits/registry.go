@@ -0,0 +1,795 @@
+package circuits
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iden3/prover-server/pkg/app/configs"
+	"github.com/iden3/prover-server/pkg/log"
+	"github.com/iden3/prover-server/pkg/proof"
+	"github.com/iden3/prover-server/pkg/scheduler"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrIllegalName is returned when circuit name escapes circuits directory
+	ErrIllegalName = errors.New("illegal circuitPath")
+	// ErrNotFound is returned when there is no circuit with the given name
+	ErrNotFound = errors.New("circuitPath doesn't exist")
+	// ErrDisabled is returned when circuit is disabled by operator
+	ErrDisabled = errors.New("circuit is disabled")
+	// ErrUnavailable is returned when circuit can't be fetched from remote store
+	ErrUnavailable = errors.New("circuit is unavailable")
+)
+
+// DefaultZKeyFactor is a ratio of peak memory of proof generation to zkey size used to estimate memory of circuits
+const DefaultZKeyFactor = 2
+
+// Circuit is a circuit loaded from circuits directory. Artifact files are copied into snapshots on load and only
+// their location and hashes are kept, artifacts are read from snapshots for proofs by Registry.Artifacts.
+// Loaded circuit is never modified, reload replaces the whole circuit.
+type Circuit struct {
+	// Name is a name from manifest or directory name
+	Name          string    `json:"name"`
+	Version       string    `json:"version,omitempty"`
+	Description   string    `json:"description,omitempty"`
+	PublicSignals []string  `json:"public_signals,omitempty"`
+	Disabled      bool      `json:"disabled"`
+	LoadedAt      time.Time `json:"loaded_at"`
+	// Dir is a name of circuit directory, or name of archive without extension if it contains a single circuit
+	Dir string `json:"dir"`
+	// Archive is a name of archive file the circuit is loaded from
+	Archive string `json:"archive,omitempty"`
+	// Path is a path of circuit directory, or path of archive joined with directory in it
+	Path string `json:"-"`
+	// Files are paths of artifact files relative to circuit directory
+	Files    proof.ArtifactFiles `json:"files"`
+	Manifest *Manifest           `json:"-"`
+	Location Location            `json:"-"`
+	// VerificationKey is kept in memory, it's small and used to verify every proof
+	VerificationKey []byte `json:"-"`
+	// Prover is a prover backend selected for the circuit by config
+	Prover proof.Prover `json:"-"`
+	// Witness is a witness calculator selected for the circuit by config
+	Witness proof.WitnessCalculator `json:"-"`
+	// BackendConfig and WitnessConfig are configs Prover and Witness are created from
+	BackendConfig configs.ProverBackendConfig `json:"-"`
+	WitnessConfig configs.WitnessConfig       `json:"-"`
+	// Timeout limits duration of proof generation, 0 is no limit
+	Timeout time.Duration `json:"-"`
+	// Memory is an estimate of peak memory of proof generation in bytes
+	Memory int64 `json:"memory_bytes"`
+	// Priority is a lane proofs of requests without priority wait in
+	Priority scheduler.Priority `json:"priority"`
+
+	// fingerprint identifies versions of files the circuit was loaded from
+	fingerprint string
+	// fsys is a file system rooted at circuit directory
+	fsys fs.FS
+	// extracted is a directory archive of the circuit is extracted to
+	extracted string
+}
+
+// ID returns reference of the circuit: name@version, or name if the circuit has no version
+func (c Circuit) ID() string {
+	return circuitID(c.Name, c.Version)
+}
+
+// ReadFile reads file of circuit directory
+func (c Circuit) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(c.fsys, name)
+}
+
+// source is a location of circuit files: directory in circuits directory or in archive
+type source struct {
+	dir string
+	// name identifies the source in load errors, it's dir or archive name joined with dir
+	name    string
+	path    string
+	archive string
+	fsys    fs.FS
+	// fingerprint is a fingerprint of archive, all circuits of archive are reloaded when it changes
+	fingerprint string
+	// extracted is a directory archive is extracted to
+	extracted string
+}
+
+// Registry keeps circuits loaded from circuits directory and archives in it, it's updated by Reload.
+// Circuits path can be an archive too. Circuits are referenced by name@version, name, alias from config or name@latest.
+type Registry struct {
+	basePath string
+	aliases  map[string]string
+	// pins are hashes pinned in config by circuit ID or directory name
+	pins map[string]Hashes
+	// layout is a global layout of circuit directories, layouts override it by circuit ID or directory name
+	layout  Layout
+	layouts map[string]Layout
+	// backend is a prover backend of circuits, backends override it by circuit ID or directory name
+	backend  configs.ProverBackendConfig
+	backends map[string]configs.ProverBackendConfig
+	// witness is a witness calculator of circuits, witnesses override it by circuit ID or directory name
+	witness   configs.WitnessConfig
+	witnesses map[string]configs.WitnessConfig
+	// timeout is a timeout of proof generation, timeouts override it by circuit ID or directory name
+	timeout  time.Duration
+	timeouts map[string]time.Duration
+	// zkeyFactor estimates memory of circuits by zkey size, memory overrides it by circuit ID or directory name
+	zkeyFactor float64
+	memory     map[string]int64
+	// priority is a default priority of circuits, priorities override it by circuit ID or directory name
+	priority   scheduler.Priority
+	priorities map[string]scheduler.Priority
+
+	// reloadMu serializes reloads and guards archives
+	reloadMu sync.Mutex
+	// archives are indexes of archives by path
+	archives map[string]*archiveIndex
+	// remote fetches circuits from remote store, it's nil if remote store isn't configured
+	remote *fetcher
+	// uploads are uploads of circuits, it's nil if circuits path isn't a directory
+	uploads *Uploads
+	// artifacts caches artifacts read for proofs
+	artifacts *ArtifactCache
+	// snapshots are copies of artifact files of loaded circuits, they're kept while circuits are loaded
+	// or acquired by proofs
+	snapshots *snapshots
+	// installMu serializes installs of uploaded circuits
+	installMu sync.Mutex
+
+	mu sync.RWMutex
+	// circuits by directory name
+	circuits map[string]*Circuit
+	// ids are circuits by ID
+	ids map[string]*Circuit
+	// loadErrors are errors of circuit directories that failed to load by the last reload
+	loadErrors map[string]string
+	// disabled circuit IDs, circuits stay disabled after reload
+	disabled map[string]bool
+}
+
+// NewRegistry creates registry of circuits located in config.CircuitsBasePath and loads them
+func NewRegistry(config configs.ProverConfig) (*Registry, error) {
+	r := &Registry{
+		basePath:   config.CircuitsBasePath,
+		aliases:    make(map[string]string, len(config.Aliases)),
+		pins:       make(map[string]Hashes, len(config.PinnedHashes)),
+		layout:     defaultLayout.override(layoutFromConfig(config.Layout)),
+		layouts:    make(map[string]Layout, len(config.Layouts)),
+		backend:    config.Backend,
+		backends:   make(map[string]configs.ProverBackendConfig, len(config.Backends)),
+		witness:    config.Witness,
+		witnesses:  make(map[string]configs.WitnessConfig, len(config.Witnesses)),
+		timeout:    config.Timeout,
+		timeouts:   make(map[string]time.Duration, len(config.Timeouts)),
+		zkeyFactor: config.Memory.ZKeyFactor,
+		memory:     make(map[string]int64, len(config.Memory.Circuits)),
+		priority:   scheduler.PriorityNormal,
+		priorities: make(map[string]scheduler.Priority, len(config.Priority.Circuits)),
+		archives:   make(map[string]*archiveIndex),
+		artifacts:  NewArtifactCache(config.Memory.ArtifactCacheMB << 20),
+		circuits:   make(map[string]*Circuit),
+		ids:        make(map[string]*Circuit),
+		loadErrors: make(map[string]string),
+		disabled:   make(map[string]bool),
+	}
+	for _, a := range config.Aliases {
+		r.aliases[a.Alias] = a.Circuit
+	}
+	for _, p := range config.PinnedHashes {
+		hashes := Hashes{Wasm: p.Wasm, ZKey: p.ZKey, VerificationKey: p.VerificationKey}
+		if err := ValidateHashes(hashes); err != nil {
+			return nil, errors.Wrapf(err, "illegal pinned hashes of circuit %s", p.Circuit)
+		}
+		r.pins[p.Circuit] = hashes
+	}
+	if err := validateLayout(r.layout); err != nil {
+		return nil, errors.Wrap(err, "illegal layout")
+	}
+	for _, l := range config.Layouts {
+		layout := layoutFromConfig(l.LayoutConfig)
+		if err := validateLayout(layout); err != nil {
+			return nil, errors.Wrapf(err, "illegal layout of circuit %s", l.Circuit)
+		}
+		r.layouts[l.Circuit] = layout
+	}
+	if _, err := proof.NewProver(r.backend); err != nil {
+		return nil, errors.Wrap(err, "illegal prover backend")
+	}
+	for _, b := range config.Backends {
+		if _, err := proof.NewProver(b.ProverBackendConfig); err != nil {
+			return nil, errors.Wrapf(err, "illegal prover backend of circuit %s", b.Circuit)
+		}
+		r.backends[b.Circuit] = b.ProverBackendConfig
+	}
+	if _, err := proof.NewWitnessCalculator(r.witness); err != nil {
+		return nil, errors.Wrap(err, "illegal witness calculator")
+	}
+	for _, w := range config.Witnesses {
+		if _, err := proof.NewWitnessCalculator(w.WitnessConfig); err != nil {
+			return nil, errors.Wrapf(err, "illegal witness calculator of circuit %s", w.Circuit)
+		}
+		r.witnesses[w.Circuit] = w.WitnessConfig
+	}
+	for _, t := range config.Timeouts {
+		r.timeouts[t.Circuit] = t.Timeout
+	}
+	if r.zkeyFactor <= 0 {
+		r.zkeyFactor = DefaultZKeyFactor
+	}
+	for _, m := range config.Memory.Circuits {
+		r.memory[m.Circuit] = m.MemoryMB << 20
+	}
+	if config.Priority.Default != "" {
+		var err error
+		if r.priority, err = scheduler.ParsePriority(config.Priority.Default); err != nil {
+			return nil, errors.Wrap(err, "illegal default priority")
+		}
+	}
+	for _, p := range config.Priority.Circuits {
+		priority, err := scheduler.ParsePriority(p.Priority)
+		if err != nil {
+			return nil, errors.Wrapf(err, "illegal priority of circuit %s", p.Circuit)
+		}
+		r.priorities[p.Circuit] = priority
+	}
+	if config.Remote.Type != "" {
+		var err error
+		if r.remote, err = newFetcher(config.Remote); err != nil {
+			return nil, err
+		}
+	}
+	if fi, err := os.Stat(r.basePath); err == nil && fi.IsDir() {
+		r.uploads = NewUploads(filepath.Join(r.basePath, uploadsDir))
+	}
+	var err error
+	if r.snapshots, err = newSnapshots(config.SnapshotPath); err != nil {
+		return nil, err
+	}
+	if err = r.Reload(); err != nil {
+		_ = r.snapshots.close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// Close removes snapshots directory if it's temporary
+func (r *Registry) Close() error {
+	return r.snapshots.close()
+}
+
+// Uploads returns uploads of circuits installed by Install, it's nil if circuits path isn't a directory
+func (r *Registry) Uploads() *Uploads {
+	return r.uploads
+}
+
+// Reload scans circuits directory, loads new and changed circuits and forgets removed ones.
+// Circuit is replaced only if its new version is valid, otherwise the previous version stays loaded.
+func (r *Registry) Reload() error {
+	return r.reload(nil)
+}
+
+// reload reloads circuits, fetched is a circuit just fetched into cache, which isn't loaded again
+func (r *Registry) reload(fetched *Circuit) error {
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+
+	loadErrors := make(map[string]string)
+	sources, err := r.sources(loadErrors)
+	if err != nil {
+		return err
+	}
+
+	r.mu.RLock()
+	current := r.circuits
+	r.mu.RUnlock()
+
+	circuits := make(map[string]*Circuit, len(sources))
+	ids := make(map[string]*Circuit, len(sources))
+	// created are circuits loaded by this reload, snapshots of those which aren't kept are released
+	var created []*Circuit
+	if fetched != nil {
+		created = append(created, fetched)
+	}
+	// sources are sorted by name and fetched circuits go last, so the first directory wins
+	// if several have the same name and version
+	for _, src := range sources {
+		var c *Circuit
+		if loaded := circuits[src.dir]; loaded != nil {
+			err = errors.Errorf("circuit directory %s is already loaded from %s", src.dir, loaded.Path)
+		} else {
+			loaded := current[src.dir]
+			if fetched != nil && fetched.Path == src.path {
+				loaded = fetched
+			}
+			c, err = r.reloadCircuit(src, loaded)
+			if err == nil && c != loaded {
+				created = append(created, c)
+			}
+		}
+		if err == nil && ids[c.ID()] != nil {
+			err = errors.Errorf("circuit %s is already loaded from %s", c.ID(), ids[c.ID()].Path)
+			c = nil
+		}
+		if err != nil {
+			log.Errorw("failed to load circuit", "circuit", src.name, "error", err)
+			loadErrors[src.name] = err.Error()
+			// keep previous version if the new one is broken
+			c = current[src.dir]
+			if c == nil || ids[c.ID()] != nil || circuits[src.dir] != nil {
+				continue
+			}
+		}
+		circuits[src.dir] = c
+		ids[c.ID()] = c
+	}
+
+	r.mu.Lock()
+	r.circuits = circuits
+	r.ids = ids
+	r.loadErrors = loadErrors
+	r.mu.Unlock()
+
+	r.removeExtracted(circuits)
+	// snapshots of replaced circuits stay until proofs that acquired them end
+	for _, c := range current {
+		if circuits[c.Dir] != c {
+			r.snapshots.release(c.Location.Files.List()...)
+		}
+	}
+	for _, c := range created {
+		if circuits[c.Dir] != c {
+			r.snapshots.release(c.Location.Files.List()...)
+		}
+	}
+	return nil
+}
+
+// sources returns circuit directories and circuits of archives followed by circuits fetched from remote store,
+// errors of broken archives are added to loadErrors
+func (r *Registry) sources(loadErrors map[string]string) ([]source, error) {
+	sources, err := r.basePathSources(loadErrors)
+	if err != nil || r.remote == nil {
+		return sources, err
+	}
+
+	entries, err := os.ReadDir(r.remote.cachePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read circuits cache")
+	}
+	for _, e := range entries {
+		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+			sources = append(sources, dirSource(r.remote.cachePath, e.Name()))
+		}
+	}
+	return sources, nil
+}
+
+// basePathSources returns circuit directories and circuits of archives of circuits path
+func (r *Registry) basePathSources(loadErrors map[string]string) ([]source, error) {
+	fi, err := os.Stat(r.basePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read circuits directory")
+	}
+	archives := make(map[string]*archiveIndex)
+	defer func() { r.archives = archives }()
+
+	if !fi.IsDir() {
+		if archiveExtension(r.basePath) == "" {
+			return nil, errors.New("circuits path is neither a directory nor an archive")
+		}
+		sources, err := r.archiveSources(r.basePath, archives, loadErrors)
+		return sources, errors.Wrap(err, "failed to read circuits archive")
+	}
+
+	entries, err := os.ReadDir(r.basePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read circuits directory")
+	}
+	var sources []source
+	for _, e := range entries {
+		// hidden files are ignored
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		if e.IsDir() {
+			sources = append(sources, dirSource(r.basePath, e.Name()))
+			continue
+		}
+		if archiveExtension(e.Name()) == "" {
+			continue
+		}
+		archiveSources, err := r.archiveSources(filepath.Join(r.basePath, e.Name()), archives, loadErrors)
+		if err != nil {
+			log.Errorw("failed to read circuits archive", "archive", e.Name(), "error", err)
+			loadErrors[e.Name()] = err.Error()
+			continue
+		}
+		sources = append(sources, archiveSources...)
+	}
+	return sources, nil
+}
+
+// dirSource returns source of circuit directory
+func dirSource(basePath, dir string) source {
+	dirPath := filepath.Join(basePath, dir)
+	return source{dir: dir, name: dir, path: dirPath, fsys: os.DirFS(dirPath)}
+}
+
+// archiveSources returns circuits of archive, archive is indexed again only if it has changed
+func (r *Registry) archiveSources(archivePath string, archives map[string]*archiveIndex,
+	loadErrors map[string]string) ([]source, error) {
+
+	fp, err := fileFingerprint(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	index := r.archives[archivePath]
+	if index == nil || index.fingerprint != fp {
+		if index, err = extractArchive(archivePath, filepath.Join(r.snapshots.dir, archivesDir)); err != nil {
+			return nil, err
+		}
+		index.fingerprint = fp
+	}
+	archives[archivePath] = index
+
+	archiveName := filepath.Base(archivePath)
+	var sources []source
+	for _, dir := range index.circuitDirs() {
+		src := source{archive: archiveName, fingerprint: fp, extracted: index.dir}
+		if dir == "." {
+			src.dir = archiveName[:len(archiveName)-len(archiveExtension(archiveName))]
+			src.name, src.path, src.fsys = archiveName, archivePath, os.DirFS(index.dir)
+			sources = append(sources, src)
+			continue
+		}
+		src.dir, src.name, src.path = dir, path.Join(archiveName, dir), filepath.Join(archivePath, dir)
+		src.fsys = os.DirFS(filepath.Join(index.dir, dir))
+		sources = append(sources, src)
+	}
+	return sources, nil
+}
+
+// removeExtracted removes directories archives were extracted to, except those of indexed archives
+// and loaded circuits
+func (r *Registry) removeExtracted(circuits map[string]*Circuit) {
+	keep := make(map[string]bool)
+	for _, index := range r.archives {
+		keep[filepath.Base(index.dir)] = true
+	}
+	for _, c := range circuits {
+		if c.extracted != "" {
+			keep[filepath.Base(c.extracted)] = true
+		}
+	}
+	archivesPath := filepath.Join(r.snapshots.dir, archivesDir)
+	entries, err := os.ReadDir(archivesPath)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		// hidden directories are archives being extracted
+		if keep[e.Name()] || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		if err = os.RemoveAll(filepath.Join(archivesPath, e.Name())); err != nil {
+			log.Errorw("failed to remove extracted archive", "archive", e.Name(), "error", err)
+		}
+	}
+}
+
+// reloadCircuit returns loaded circuit if its files haven't changed, or loads it again
+func (r *Registry) reloadCircuit(src source, loaded *Circuit) (*Circuit, error) {
+	if err := validateName(src.dir); err != nil {
+		return nil, err
+	}
+	// archive isn't read again if it hasn't changed
+	if src.fingerprint != "" && loaded != nil && loaded.fingerprint == src.fingerprint {
+		return loaded, nil
+	}
+
+	manifest, err := ReadManifest(src.fsys)
+	if err != nil {
+		return nil, err
+	}
+	files, err := r.artifactFiles(src.dir, manifest)
+	if err != nil {
+		return nil, err
+	}
+	fp := src.fingerprint
+	if fp == "" {
+		if fp, err = fingerprint(src.fsys, files); err != nil {
+			return nil, err
+		}
+		if loaded != nil && loaded.fingerprint == fp {
+			return loaded, nil
+		}
+	}
+
+	c, err := r.load(src, manifest, files, fp)
+	if err != nil {
+		return nil, err
+	}
+	if loaded != nil {
+		log.Infow("Circuit updated", "circuit", c.ID(), "source", src.name)
+	} else {
+		log.Infow("Circuit loaded", "circuit", c.ID(), "source", src.name)
+	}
+	return c, nil
+}
+
+// artifactFiles returns paths of artifacts of the circuit: files from manifest override layout of the circuit
+// from config, which overrides global layout
+func (r *Registry) artifactFiles(dir string, manifest *Manifest) (proof.ArtifactFiles, error) {
+	name, layout := dir, r.layout
+	if manifest != nil && manifest.Name != "" {
+		name = manifest.Name
+	}
+	if manifest != nil {
+		layout = layout.override(r.layouts[circuitID(name, manifest.Version)])
+	}
+	layout = layout.override(r.layouts[dir])
+	if manifest != nil {
+		layout = layout.override(manifest.Files)
+	}
+	return layout.files(name, dir)
+}
+
+// Get returns enabled circuit by reference: name@version, name, alias or name@latest
+func (r *Registry) Get(ref string) (Circuit, error) {
+	return r.get(ref, false)
+}
+
+// get returns enabled circuit by reference, snapshots of the circuit are acquired if pin is set
+func (r *Registry) get(ref string, pin bool) (Circuit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, err := r.resolve(ref)
+	if err != nil {
+		return Circuit{}, err
+	}
+	if r.disabled[c.ID()] {
+		return Circuit{}, ErrDisabled
+	}
+	if pin {
+		// circuit can't be replaced while r.mu is locked, so its snapshots exist
+		r.snapshots.acquire(c.Location.Files.List()...)
+	}
+	return *c, nil
+}
+
+// resolve finds circuit by reference, must be called with r.mu locked
+func (r *Registry) resolve(ref string) (*Circuit, error) {
+	if target, ok := r.aliases[ref]; ok {
+		ref = target
+	}
+
+	name, version := splitRef(ref)
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+
+	switch version {
+	case "":
+		if c, ok := r.ids[name]; ok {
+			return c, nil
+		}
+		// directory name refers to the circuit for compatibility with circuits without manifest
+		if c, ok := r.circuits[name]; ok {
+			return c, nil
+		}
+		return r.latest(name)
+	case LatestVersion:
+		return r.latest(name)
+	default:
+		if c, ok := r.ids[ref]; ok {
+			return c, nil
+		}
+		return nil, ErrNotFound
+	}
+}
+
+// latest returns the highest version of the circuit, must be called with r.mu locked
+func (r *Registry) latest(name string) (*Circuit, error) {
+	var latest *Circuit
+	for _, c := range r.ids {
+		if c.Name == name && (latest == nil || compareVersions(c.Version, latest.Version) > 0) {
+			latest = c
+		}
+	}
+	if latest == nil {
+		return nil, ErrNotFound
+	}
+	return latest, nil
+}
+
+// List returns all circuits sorted by name and version, including disabled ones
+func (r *Registry) List() []Circuit {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]Circuit, 0, len(r.ids))
+	for id, c := range r.ids {
+		c := *c
+		c.Disabled = r.disabled[id]
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Name != list[j].Name {
+			return list[i].Name < list[j].Name
+		}
+		return compareVersions(list[i].Version, list[j].Version) < 0
+	})
+	return list
+}
+
+// Aliases returns circuit aliases from config
+func (r *Registry) Aliases() map[string]string {
+	aliases := make(map[string]string, len(r.aliases))
+	for alias, target := range r.aliases {
+		aliases[alias] = target
+	}
+	return aliases
+}
+
+// LoadErrors returns errors of circuit directories that failed to load by the last reload
+func (r *Registry) LoadErrors() map[string]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	loadErrors := make(map[string]string, len(r.loadErrors))
+	for name, err := range r.loadErrors {
+		loadErrors[name] = err
+	}
+	return loadErrors
+}
+
+// Artifacts returns artifacts of the circuit for proof generation, they're read from snapshots of circuit files
+// unless they're cached. Snapshots of the circuit must be acquired by Acquire while they're used.
+func (r *Registry) Artifacts(ctx context.Context, c Circuit) (*proof.Artifacts, error) {
+	return r.artifacts.Get(ctx, c.Location, c.Prover)
+}
+
+// SetDisabled disables or enables circuit by reference, requests for disabled circuit fail with ErrDisabled.
+// It returns ID of the circuit.
+func (r *Registry) SetDisabled(ref string, disabled bool) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, err := r.resolve(ref)
+	if err != nil {
+		return "", err
+	}
+	if disabled {
+		r.disabled[c.ID()] = true
+	} else {
+		delete(r.disabled, c.ID())
+	}
+	return c.ID(), nil
+}
+
+// load copies artifacts of the circuit into snapshots, verifies hashes pinned in manifest and config and
+// validates the snapshots. Artifacts are read once and aren't kept in memory. Snapshots of returned circuit
+// are referred to until they're released.
+func (r *Registry) load(src source, manifest *Manifest, files proof.ArtifactFiles, fp string) (c *Circuit, err error) {
+	c = &Circuit{
+		Name:        src.dir,
+		Dir:         src.dir,
+		Archive:     src.archive,
+		Path:        src.path,
+		Files:       files,
+		LoadedAt:    time.Now(),
+		Manifest:    manifest,
+		fingerprint: fp,
+		fsys:        src.fsys,
+		extracted:   src.extracted,
+	}
+	if manifest != nil {
+		if manifest.Name != "" {
+			c.Name = manifest.Name
+		}
+		c.Version = manifest.Version
+		c.Description = manifest.Description
+		c.PublicSignals = manifest.PublicSignals
+	}
+
+	var pinned []Hashes
+	var descriptions []string
+	if manifest != nil {
+		pinned, descriptions = append(pinned, manifest.Hashes), append(descriptions, "pinned hashes from manifest")
+	}
+	for _, ref := range []string{c.ID(), src.dir} {
+		pinned, descriptions = append(pinned, r.pins[ref]), append(descriptions, "pinned hashes from config")
+	}
+	sizes, err := hashFiles(src.fsys, files, pinned, descriptions, r.snapshots)
+	if err != nil {
+		return nil, err
+	}
+	c.Location = Location{Path: r.snapshots.dir, Files: sizes.snapshots, Hashes: sizes.hashes}
+	defer func() {
+		if err != nil {
+			r.snapshots.release(sizes.snapshots.List()...)
+		}
+	}()
+	if c.VerificationKey, err = proof.ValidateFiles(os.DirFS(r.snapshots.dir), sizes.snapshots); err != nil {
+		return nil, err
+	}
+
+	backend, witness, timeout, priority := r.backend, r.witness, r.timeout, r.priority
+	// proving allocates memory proportional to zkey, witness calculation instantiates wasm
+	c.Memory = int64(float64(sizes.zkey)*r.zkeyFactor) + sizes.wasm
+	if manifest != nil && manifest.MemoryMB > 0 {
+		c.Memory = manifest.MemoryMB << 20
+	}
+	for _, ref := range []string{c.ID(), src.dir} {
+		if b, ok := r.backends[ref]; ok {
+			backend = b
+		}
+		if w, ok := r.witnesses[ref]; ok {
+			witness = w
+		}
+		if t, ok := r.timeouts[ref]; ok {
+			timeout = t
+		}
+		if m, ok := r.memory[ref]; ok {
+			c.Memory = m
+		}
+		if p, ok := r.priorities[ref]; ok {
+			priority = p
+		}
+	}
+	c.Timeout = timeout
+	c.Priority = priority
+	// prover and witness calculator are created per circuit, so they can keep state of the circuit
+	if c.Prover, err = proof.NewProver(backend); err != nil {
+		return nil, err
+	}
+	witness.Path = strings.NewReplacer(NamePlaceholder, c.Name, DirPlaceholder, c.Dir).Replace(witness.Path)
+	if c.Witness, err = proof.NewWitnessCalculator(witness); err != nil {
+		return nil, err
+	}
+	c.BackendConfig, c.WitnessConfig = backend, witness
+	return c, nil
+}
+
+// fingerprint returns sizes and modification times of manifest and artifact files,
+// which change when files are replaced
+func fingerprint(fsys fs.FS, files proof.ArtifactFiles) (string, error) {
+	var b strings.Builder
+	for _, f := range append([]string{ManifestFile}, files.List()...) {
+		fi, err := fs.Stat(fsys, f)
+		if errors.Is(err, fs.ErrNotExist) && f == ManifestFile {
+			continue
+		}
+		if err != nil {
+			return "", errors.Wrapf(err, "missing %s", f)
+		}
+		fmt.Fprintf(&b, "%s:%d:%d;", f, fi.Size(), fi.ModTime().UnixNano())
+	}
+	return b.String(), nil
+}
+
+// fileFingerprint returns size and modification time of the file
+func fileFingerprint(filePath string) (string, error) {
+	fi, err := os.Stat(filePath)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read archive")
+	}
+	return fmt.Sprintf("%d:%d", fi.Size(), fi.ModTime().UnixNano()), nil
+}
+
+// validateName checks that name is a single clean path element
+func validateName(name string) error {
+	if name == "" || path.Clean(name) != name || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return ErrIllegalName
+	}
+	return nil
+}
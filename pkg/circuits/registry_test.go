@@ -0,0 +1,458 @@
+package circuits
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iden3/prover-server/pkg/app/configs"
+	"github.com/iden3/prover-server/pkg/circuits/circuitstest"
+	"github.com/iden3/prover-server/pkg/proof"
+	"github.com/iden3/prover-server/pkg/scheduler"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/blake2b"
+)
+
+func TestRegistry(t *testing.T) {
+	basePath := t.TempDir()
+	authPath := circuitstest.WriteCircuit(t, basePath, "auth")
+	require.NoError(t, os.WriteFile(filepath.Join(basePath, "readme.txt"), nil, 0o600))
+
+	r, err := NewRegistry(configs.ProverConfig{CircuitsBasePath: basePath})
+	require.NoError(t, err)
+	defer r.Close()
+	list := r.List()
+	require.Len(t, list, 1)
+	require.Equal(t, "auth", list[0].Name)
+
+	c, err := r.Get("auth")
+	require.NoError(t, err)
+	require.Equal(t, authPath, c.Path)
+	require.Equal(t, circuitstest.Artifacts[proof.ZKeyFile], readArtifacts(t, r, c).ZKey)
+	require.Equal(t, circuitstest.Artifacts[proof.VerificationKeyFile], c.VerificationKey)
+
+	for _, name := range []string{"", ".", "..", "../auth", "auth/", "a/b"} {
+		_, err = r.Get(name)
+		require.ErrorIs(t, err, ErrIllegalName, name)
+	}
+	_, err = r.Get("unknown")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	// new circuits are loaded on reload, disabled state is kept
+	_, err = r.SetDisabled("auth", true)
+	require.NoError(t, err)
+	circuitstest.WriteCircuit(t, basePath, "stateTransition")
+	require.NoError(t, r.Reload())
+
+	_, err = r.Get("auth")
+	require.ErrorIs(t, err, ErrDisabled)
+	_, err = r.Get("stateTransition")
+	require.NoError(t, err)
+	list = r.List()
+	require.Len(t, list, 2)
+	require.True(t, list[0].Disabled)
+
+	_, err = r.SetDisabled("auth", false)
+	require.NoError(t, err)
+	_, err = r.Get("auth")
+	require.NoError(t, err)
+
+	_, err = r.SetDisabled("unknown", true)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	// removed circuits are forgotten
+	require.NoError(t, os.RemoveAll(filepath.Join(basePath, "stateTransition")))
+	require.NoError(t, r.Reload())
+	_, err = r.Get("stateTransition")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestRegistryKeepsPreviousVersionOfInvalidCircuit(t *testing.T) {
+	basePath := t.TempDir()
+	authPath := circuitstest.WriteCircuit(t, basePath, "auth")
+	require.NoError(t, os.Mkdir(filepath.Join(basePath, "incomplete"), 0o755))
+
+	r, err := NewRegistry(configs.ProverConfig{CircuitsBasePath: basePath})
+	require.NoError(t, err)
+	defer r.Close()
+	_, err = r.Get("incomplete")
+	require.ErrorIs(t, err, ErrNotFound)
+	require.Contains(t, r.LoadErrors(), "incomplete")
+
+	ctx := context.Background()
+	loaded, release, err := r.Acquire(ctx, "auth")
+	require.NoError(t, err)
+
+	// broken update isn't loaded
+	require.NoError(t, os.WriteFile(filepath.Join(authPath, proof.ZKeyFile), []byte("broken"), 0o600))
+	require.NoError(t, r.Reload())
+	c, err := r.Get("auth")
+	require.NoError(t, err)
+	require.Equal(t, loaded.LoadedAt, c.LoadedAt)
+	require.Contains(t, r.LoadErrors(), "auth")
+
+	// valid update replaces the circuit, acquired previous version reads files it was loaded with
+	zkey := append([]byte("zkey"), 2, 0, 0, 0)
+	require.NoError(t, os.WriteFile(filepath.Join(authPath, proof.ZKeyFile), zkey, 0o600))
+	require.NoError(t, r.Reload())
+	c, err = r.Get("auth")
+	require.NoError(t, err)
+	require.Equal(t, zkey, readArtifacts(t, r, c).ZKey)
+	require.Equal(t, circuitstest.Artifacts[proof.ZKeyFile], readArtifacts(t, r, loaded).ZKey)
+	require.NotContains(t, r.LoadErrors(), "auth")
+
+	// snapshots of previous version are removed when its last proof ends
+	release()
+	release()
+	_, err = os.Stat(filepath.Join(loaded.Location.Path, loaded.Location.Files.ZKey))
+	require.ErrorIs(t, err, fs.ErrNotExist)
+	_, err = os.Stat(filepath.Join(c.Location.Path, c.Location.Files.ZKey))
+	require.NoError(t, err)
+}
+
+func TestRegistryVersionsAndAliases(t *testing.T) {
+	basePath := t.TempDir()
+	writeManifest(t, circuitstest.WriteCircuit(t, basePath, "auth"), "name: auth\nversion: 1.0.0\n")
+	writeManifest(t, circuitstest.WriteCircuit(t, basePath, "authV2"),
+		"name: auth\nversion: 2.0.0\ndescription: Auth v2\npublicSignals: [userID, challenge]\n")
+	writeManifest(t, circuitstest.WriteCircuit(t, basePath, "authV10"), "name: auth\nversion: 10.0.0\n")
+	writeManifest(t, circuitstest.WriteCircuit(t, basePath, "authCopy"), "name: auth\nversion: 1.0.0\n")
+	writeManifest(t, circuitstest.WriteCircuit(t, basePath, "broken"), "version: latest\n")
+
+	r, err := NewRegistry(configs.ProverConfig{
+		CircuitsBasePath: basePath,
+		Aliases: []configs.AliasConfig{
+			{Alias: "auth@stable", Circuit: "auth@2.0.0"},
+			{Alias: "auth@latest", Circuit: "auth@1.0.0"},
+		},
+	})
+	require.NoError(t, err)
+	defer r.Close()
+
+	list := r.List()
+	require.Len(t, list, 3)
+	require.Equal(t, []string{"auth@1.0.0", "auth@2.0.0", "auth@10.0.0"},
+		[]string{list[0].ID(), list[1].ID(), list[2].ID()})
+	require.Equal(t, "Auth v2", list[1].Description)
+	require.Equal(t, []string{"userID", "challenge"}, list[1].PublicSignals)
+	require.Contains(t, r.LoadErrors(), "authCopy")
+	require.Contains(t, r.LoadErrors(), "broken")
+
+	for ref, dir := range map[string]string{
+		"auth@1.0.0":  "auth",
+		"auth@2.0.0":  "authV2",
+		"auth@stable": "authV2",
+		"auth@latest": "auth",
+		"auth":        "auth",
+		"authV10":     "authV10",
+	} {
+		c, err := r.Get(ref)
+		require.NoError(t, err, ref)
+		require.Equal(t, dir, c.Dir, ref)
+	}
+	_, err = r.Get("auth@3.0.0")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	// disabled state is kept by version
+	id, err := r.SetDisabled("auth@stable", true)
+	require.NoError(t, err)
+	require.Equal(t, "auth@2.0.0", id)
+	_, err = r.Get("auth@2.0.0")
+	require.ErrorIs(t, err, ErrDisabled)
+	_, err = r.Get("auth@1.0.0")
+	require.NoError(t, err)
+}
+
+func TestLatestVersionWithoutAlias(t *testing.T) {
+	basePath := t.TempDir()
+	writeManifest(t, circuitstest.WriteCircuit(t, basePath, "v1"), "name: auth\nversion: v1.2.0\n")
+	writeManifest(t, circuitstest.WriteCircuit(t, basePath, "v2"), "name: auth\nversion: v1.10.0\n")
+
+	r, err := NewRegistry(configs.ProverConfig{CircuitsBasePath: basePath})
+	require.NoError(t, err)
+	defer r.Close()
+	for _, ref := range []string{"auth", "auth@latest"} {
+		c, err := r.Get(ref)
+		require.NoError(t, err)
+		require.Equal(t, "v2", c.Dir)
+	}
+}
+
+func TestRegistryVerifiesPinnedHashes(t *testing.T) {
+	zkeySHA256 := sha256.Sum256(circuitstest.Artifacts[proof.ZKeyFile])
+	wasmBlake2b := blake2b.Sum256(circuitstest.Artifacts[proof.WasmFile])
+	wrongHash := "sha256:" + hex.EncodeToString(make([]byte, sha256.Size))
+
+	basePath := t.TempDir()
+	writeManifest(t, circuitstest.WriteCircuit(t, basePath, "auth"),
+		"name: auth\nversion: 1.0.0\nhashes:\n  zkey: "+hex.EncodeToString(zkeySHA256[:])+"\n")
+	writeManifest(t, circuitstest.WriteCircuit(t, basePath, "tampered"),
+		"hashes:\n  verificationKey: "+wrongHash+"\n")
+	circuitstest.WriteCircuit(t, basePath, "stateTransition")
+	circuitstest.WriteCircuit(t, basePath, "sig")
+
+	r, err := NewRegistry(configs.ProverConfig{
+		CircuitsBasePath: basePath,
+		PinnedHashes: []configs.PinnedHashesConfig{
+			{Circuit: "auth@1.0.0", Wasm: "blake2b:" + hex.EncodeToString(wasmBlake2b[:])},
+			{Circuit: "stateTransition", ZKey: "BLAKE2B:" + hex.EncodeToString(wasmBlake2b[:])},
+		},
+	})
+	require.NoError(t, err)
+	defer r.Close()
+
+	_, err = r.Get("auth@1.0.0")
+	require.NoError(t, err)
+	_, err = r.Get("sig")
+	require.NoError(t, err)
+	for _, name := range []string{"tampered", "stateTransition"} {
+		_, err = r.Get(name)
+		require.ErrorIs(t, err, ErrNotFound, name)
+		require.Contains(t, r.LoadErrors()[name], "hash mismatch", name)
+	}
+
+	// malformed hashes are rejected
+	_, err = NewRegistry(configs.ProverConfig{
+		CircuitsBasePath: basePath,
+		PinnedHashes:     []configs.PinnedHashesConfig{{Circuit: "sig", ZKey: "md5:00"}},
+	})
+	require.Error(t, err)
+	_, err = NewRegistry(configs.ProverConfig{
+		CircuitsBasePath: basePath,
+		PinnedHashes:     []configs.PinnedHashesConfig{{Circuit: "sig", ZKey: "sha256:abc"}},
+	})
+	require.Error(t, err)
+}
+
+func TestRegistryLayouts(t *testing.T) {
+	basePath := t.TempDir()
+	writeFiles := func(dir string, files map[string]string) {
+		for f, artifact := range files {
+			filePath := filepath.Join(basePath, dir, filepath.FromSlash(f))
+			require.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0o755))
+			require.NoError(t, os.WriteFile(filePath, circuitstest.Artifacts[artifact], 0o600))
+		}
+	}
+	// global layout
+	writeFiles("authV2", map[string]string{
+		"authV2_js/authV2.wasm": proof.WasmFile, "authV2.zkey": proof.ZKeyFile, "vkey.json": proof.VerificationKeyFile,
+	})
+	// layout of the circuit from config
+	writeFiles("sig", map[string]string{
+		"sig.wasm": proof.WasmFile, "sig.zkey": proof.ZKeyFile, "vkey.json": proof.VerificationKeyFile,
+	})
+	// files from manifest
+	writeFiles("mtp", map[string]string{
+		"build/mtp.wasm": proof.WasmFile, "sig.zkey": proof.ZKeyFile, "vkey.json": proof.VerificationKeyFile,
+	})
+	writeManifest(t, filepath.Join(basePath, "mtp"), "name: mtp\nfiles:\n  wasm: build/{name}.wasm\n")
+	writeFiles("escape", map[string]string{"vkey.json": proof.VerificationKeyFile})
+	writeManifest(t, filepath.Join(basePath, "escape"), "files:\n  zkey: ../sig/sig.zkey\n")
+
+	r, err := NewRegistry(configs.ProverConfig{
+		CircuitsBasePath: basePath,
+		Layout: configs.LayoutConfig{
+			Wasm:            "{name}_js/{name}.wasm",
+			ZKey:            "{dir}.zkey",
+			VerificationKey: "vkey.json",
+		},
+		Layouts: []configs.CircuitLayoutConfig{
+			{Circuit: "sig", LayoutConfig: configs.LayoutConfig{Wasm: "sig.wasm"}},
+			{Circuit: "mtp", LayoutConfig: configs.LayoutConfig{Wasm: "mtp.wasm", ZKey: "sig.zkey"}},
+		},
+	})
+	require.NoError(t, err)
+	defer r.Close()
+
+	for name, wasm := range map[string]string{
+		"authV2": "authV2_js/authV2.wasm",
+		"sig":    "sig.wasm",
+		"mtp":    "build/mtp.wasm",
+	} {
+		c, err := r.Get(name)
+		require.NoError(t, err, name)
+		require.Equal(t, wasm, c.Files.Wasm, name)
+		require.Equal(t, circuitstest.Artifacts[proof.WasmFile], readArtifacts(t, r, c).Wasm, name)
+	}
+	require.Contains(t, r.LoadErrors()["escape"], "illegal")
+
+	_, err = NewRegistry(configs.ProverConfig{
+		CircuitsBasePath: basePath,
+		Layout:           configs.LayoutConfig{ZKey: "/etc/{name}.zkey"},
+	})
+	require.Error(t, err)
+}
+
+func readArtifacts(t *testing.T, r *Registry, c Circuit) *proof.Artifacts {
+	t.Helper()
+	artifacts, err := r.Artifacts(context.Background(), c)
+	require.NoError(t, err)
+	return artifacts
+}
+
+func writeManifest(t *testing.T, circuitPath, manifest string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(circuitPath, ManifestFile), []byte(manifest), 0o600))
+}
+
+func TestRegistryWatch(t *testing.T) {
+	basePath := t.TempDir()
+
+	r, err := NewRegistry(configs.ProverConfig{CircuitsBasePath: basePath})
+	require.NoError(t, err)
+	defer r.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, r.Watch(ctx, 10*time.Millisecond))
+
+	circuitstest.WriteCircuit(t, basePath, "auth")
+	require.Eventually(t, func() bool {
+		_, err := r.Get("auth")
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// changes inside circuit directory are watched too
+	zkey := append([]byte("zkey"), 2, 0, 0, 0)
+	zkeySum := sha256.Sum256(zkey)
+	zkeyHash := "sha256:" + hex.EncodeToString(zkeySum[:])
+	require.NoError(t, os.WriteFile(filepath.Join(basePath, "auth", proof.ZKeyFile), zkey, 0o600))
+	require.Eventually(t, func() bool {
+		c, err := r.Get("auth")
+		return err == nil && c.Location.Hashes.ZKey == zkeyHash
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestRegistryProverBackends(t *testing.T) {
+	basePath := t.TempDir()
+	circuitstest.WriteCircuit(t, basePath, "auth")
+	circuitstest.WriteCircuit(t, basePath, "sig")
+
+	r, err := NewRegistry(configs.ProverConfig{
+		CircuitsBasePath: basePath,
+		Backend:          configs.ProverBackendConfig{Type: proof.BackendGnark},
+		Backends: []configs.CircuitBackendConfig{
+			{Circuit: "sig", ProverBackendConfig: configs.ProverBackendConfig{Type: proof.BackendBinary, Path: "/bin/prover"}},
+		},
+		Timeout:  time.Minute,
+		Timeouts: []configs.CircuitTimeoutConfig{{Circuit: "sig", Timeout: time.Second}},
+	})
+	require.NoError(t, err)
+	defer r.Close()
+
+	c, err := r.Get("auth")
+	require.NoError(t, err)
+	require.IsType(t, &proof.GnarkProver{}, c.Prover)
+	require.Equal(t, time.Minute, c.Timeout)
+	c, err = r.Get("sig")
+	require.NoError(t, err)
+	require.IsType(t, &proof.BinaryProver{}, c.Prover)
+	require.Equal(t, time.Second, c.Timeout)
+
+	_, err = NewRegistry(configs.ProverConfig{
+		CircuitsBasePath: basePath,
+		Backends: []configs.CircuitBackendConfig{
+			{Circuit: "auth", ProverBackendConfig: configs.ProverBackendConfig{Type: proof.BackendBinary}},
+		},
+	})
+	require.Error(t, err)
+}
+
+func TestRegistryWitnessCalculators(t *testing.T) {
+	basePath := t.TempDir()
+	circuitstest.WriteCircuit(t, basePath, "auth")
+	circuitstest.WriteCircuit(t, basePath, "sig")
+
+	r, err := NewRegistry(configs.ProverConfig{
+		CircuitsBasePath: basePath,
+		Witness:          configs.WitnessConfig{Type: proof.WitnessWazero},
+		Witnesses: []configs.CircuitWitnessConfig{
+			{Circuit: "sig", WitnessConfig: configs.WitnessConfig{Type: proof.WitnessBinary, Path: "/opt/{dir}_cpp/{name}"}},
+		},
+	})
+	require.NoError(t, err)
+	defer r.Close()
+
+	c, err := r.Get("auth")
+	require.NoError(t, err)
+	require.IsType(t, &proof.WazeroCalculator{}, c.Witness)
+	c, err = r.Get("sig")
+	require.NoError(t, err)
+	require.Equal(t, &proof.BinaryWitnessCalculator{Path: "/opt/sig_cpp/sig"}, c.Witness)
+
+	_, err = NewRegistry(configs.ProverConfig{
+		CircuitsBasePath: basePath,
+		Witness:          configs.WitnessConfig{Type: "snarkjs"},
+	})
+	require.Error(t, err)
+}
+
+func TestRegistryMemoryEstimates(t *testing.T) {
+	basePath := t.TempDir()
+	circuitstest.WriteCircuit(t, basePath, "auth")
+	writeManifest(t, circuitstest.WriteCircuit(t, basePath, "sig"), "name: sig\nmemoryMB: 512\n")
+	writeManifest(t, circuitstest.WriteCircuit(t, basePath, "mtp"), "name: mtp\nmemoryMB: 512\n")
+
+	r, err := NewRegistry(configs.ProverConfig{
+		CircuitsBasePath: basePath,
+		Memory: configs.MemoryConfig{
+			ZKeyFactor: 3,
+			Circuits:   []configs.CircuitMemoryConfig{{Circuit: "mtp", MemoryMB: 1024}},
+		},
+	})
+	require.NoError(t, err)
+	defer r.Close()
+
+	zkeySize := int64(len(circuitstest.Artifacts[proof.ZKeyFile]))
+	wasmSize := int64(len(circuitstest.Artifacts[proof.WasmFile]))
+	c, err := r.Get("auth")
+	require.NoError(t, err)
+	require.Equal(t, 3*zkeySize+wasmSize, c.Memory)
+	c, err = r.Get("sig")
+	require.NoError(t, err)
+	require.Equal(t, int64(512<<20), c.Memory)
+	c, err = r.Get("mtp")
+	require.NoError(t, err)
+	require.Equal(t, int64(1024<<20), c.Memory)
+
+	r, err = NewRegistry(configs.ProverConfig{CircuitsBasePath: basePath})
+	require.NoError(t, err)
+	defer r.Close()
+	c, err = r.Get("auth")
+	require.NoError(t, err)
+	require.Equal(t, DefaultZKeyFactor*zkeySize+wasmSize, c.Memory)
+}
+
+func TestRegistryPriorities(t *testing.T) {
+	basePath := t.TempDir()
+	circuitstest.WriteCircuit(t, basePath, "auth")
+	circuitstest.WriteCircuit(t, basePath, "sig")
+
+	r, err := NewRegistry(configs.ProverConfig{
+		CircuitsBasePath: basePath,
+		Priority: configs.PriorityConfig{
+			Default:  "low",
+			Circuits: []configs.CircuitPriorityConfig{{Circuit: "auth", Priority: "high"}},
+		},
+	})
+	require.NoError(t, err)
+	defer r.Close()
+
+	c, err := r.Get("auth")
+	require.NoError(t, err)
+	require.Equal(t, scheduler.PriorityHigh, c.Priority)
+	c, err = r.Get("sig")
+	require.NoError(t, err)
+	require.Equal(t, scheduler.PriorityLow, c.Priority)
+
+	_, err = NewRegistry(configs.ProverConfig{
+		CircuitsBasePath: basePath,
+		Priority:         configs.PriorityConfig{Circuits: []configs.CircuitPriorityConfig{{Circuit: "auth", Priority: "urgent"}}},
+	})
+	require.Error(t, err)
+}
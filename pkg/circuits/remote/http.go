@@ -0,0 +1,30 @@
+package remote
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// HTTPStore fetches files of circuit directories from <base URL>/<dir>/<file>
+type HTTPStore struct {
+	baseURL *url.URL
+	client  *http.Client
+}
+
+// NewHTTPStore creates new instance of HTTPStore
+func NewHTTPStore(baseURL *url.URL, client *http.Client) *HTTPStore {
+	return &HTTPStore{baseURL: baseURL, client: client}
+}
+
+// Open returns content of the file of circuit directory
+func (s *HTTPStore) Open(ctx context.Context, dir, file string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, joinURL(s.baseURL, dir, file).String(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+	return get(s.client, req)
+}
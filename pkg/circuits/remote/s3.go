@@ -0,0 +1,154 @@
+package remote
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultS3Region = "us-east-1"
+	amzDateFormat   = "20060102T150405Z"
+	// emptyPayloadHash is SHA-256 of empty body of GET request
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// Credentials are credentials of S3-compatible store, requests are anonymous if AccessKey is empty
+type Credentials struct {
+	Region    string
+	AccessKey string
+	SecretKey string
+}
+
+// S3Store fetches files of circuit directories from <endpoint>/<bucket>/<prefix>/<dir>/<file>
+// of S3-compatible store with path-style requests signed by AWS Signature Version 4
+type S3Store struct {
+	endpoint    *url.URL
+	bucket      string
+	prefix      string
+	credentials Credentials
+	client      *http.Client
+	now         func() time.Time
+}
+
+// NewS3Store creates new instance of S3Store
+func NewS3Store(endpoint *url.URL, bucket, prefix string, credentials Credentials, client *http.Client) *S3Store {
+	if credentials.Region == "" {
+		credentials.Region = defaultS3Region
+	}
+	return &S3Store{
+		endpoint:    endpoint,
+		bucket:      bucket,
+		prefix:      prefix,
+		credentials: credentials,
+		client:      client,
+		now:         time.Now,
+	}
+}
+
+// Open returns content of the file of circuit directory
+func (s *S3Store) Open(ctx context.Context, dir, file string) (io.ReadCloser, error) {
+	u := joinURL(s.endpoint, s.bucket, s.prefix, dir, file)
+	// path is encoded as required by signature
+	u.RawPath = awsURIEncode(u.Path, false)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+	if s.credentials.AccessKey != "" {
+		signV4(req, s.credentials, s.now())
+	}
+	return get(s.client, req)
+}
+
+// signV4 adds AWS Signature Version 4 of request without body to its headers
+func signV4(req *http.Request, c Credentials, now time.Time) {
+	amzDate := now.UTC().Format(amzDateFormat)
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", amzDate[:8], c.Region)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+
+	// host, range and x-amz-* headers are signed
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "range" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, headers[name])
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		awsURIEncode(req.URL.Path, false),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		emptyPayloadHash,
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256", amzDate, scope, hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.SecretKey), amzDate[:8])
+	for _, s := range []string{c.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, s)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.AccessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery returns query parameters sorted by name and encoded as required by signature
+func canonicalQuery(query url.Values) string {
+	params := make([]string, 0, len(query))
+	for name, values := range query {
+		for _, v := range values {
+			params = append(params, awsURIEncode(name, true)+"="+awsURIEncode(v, true))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// awsURIEncode encodes all characters except unreserved ones, slash is encoded only if encodeSlash is set
+func awsURIEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,132 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/iden3/prover-server/pkg/app/configs"
+	"github.com/pkg/errors"
+)
+
+// Timeouts of requests to remote store. Artifacts can be large, so whole download isn't limited,
+// but it fails when no data is received within idleTimeout.
+const (
+	dialTimeout         = 10 * time.Second
+	tlsHandshakeTimeout = 10 * time.Second
+)
+
+var idleTimeout = time.Minute
+
+// Store is a remote store of circuit directories
+type Store interface {
+	// Open returns content of the file of circuit directory, error wraps fs.ErrNotExist if there is no such file
+	Open(ctx context.Context, dir, file string) (io.ReadCloser, error)
+}
+
+// NewStore creates store of the configured type
+func NewStore(config configs.RemoteConfig) (Store, error) {
+	if config.URL == "" {
+		return nil, errors.New("remote store url is not set")
+	}
+	baseURL, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, errors.Wrap(err, "illegal remote store url")
+	}
+
+	switch config.Type {
+	case "http":
+		return NewHTTPStore(baseURL, newClient()), nil
+	case "s3":
+		if config.Bucket == "" {
+			return nil, errors.New("s3 bucket is not set")
+		}
+		return NewS3Store(baseURL, config.Bucket, config.Prefix, Credentials{
+			Region:    config.Region,
+			AccessKey: config.AccessKey,
+			SecretKey: config.SecretKey,
+		}, newClient()), nil
+	default:
+		return nil, errors.Errorf("unknown remote store type %q", config.Type)
+	}
+}
+
+// newClient returns HTTP client with timeouts of connection and TLS handshake
+func newClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: dialTimeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = tlsHandshakeTimeout
+	return &http.Client{Transport: transport}
+}
+
+// get sends request and returns response body if it's successful. Request is cancelled when response
+// or the next data of its body isn't received within idleTimeout.
+func get(client *http.Client, req *http.Request) (io.ReadCloser, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	timer := time.AfterFunc(idleTimeout, cancel)
+	body := &idleTimeoutBody{timer: timer, cancel: cancel}
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		body.stop()
+		return nil, errors.Wrap(err, "failed to fetch circuit file")
+	}
+	body.ReadCloser = resp.Body
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		_ = body.Close()
+		return nil, &fs.PathError{Op: "fetch", Path: req.URL.Path, Err: fs.ErrNotExist}
+	case resp.StatusCode != http.StatusOK:
+		_ = body.Close()
+		return nil, errors.Errorf("failed to fetch %s: unexpected status %d", req.URL.Path, resp.StatusCode)
+	}
+	return body, nil
+}
+
+// idleTimeoutBody is a response body which cancels its request when no data is read within idleTimeout
+type idleTimeoutBody struct {
+	io.ReadCloser
+	timer  *time.Timer
+	cancel context.CancelFunc
+}
+
+func (b *idleTimeoutBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if !b.timer.Reset(idleTimeout) {
+		// timer has fired and cancelled the request
+		return n, errors.Errorf("no data received within %s", idleTimeout)
+	}
+	return n, err
+}
+
+func (b *idleTimeoutBody) Close() error {
+	b.stop()
+	return b.ReadCloser.Close()
+}
+
+func (b *idleTimeoutBody) stop() {
+	b.timer.Stop()
+	b.cancel()
+}
+
+// joinURL appends escaped path elements to the base URL
+func joinURL(base *url.URL, elem ...string) *url.URL {
+	u := *base
+	escaped := make([]string, 0, len(elem))
+	for _, e := range elem {
+		for _, s := range strings.Split(e, "/") {
+			if s != "" {
+				escaped = append(escaped, url.PathEscape(s))
+			}
+		}
+	}
+	u.RawPath = fmt.Sprintf("%s/%s", strings.TrimSuffix(base.EscapedPath(), "/"), strings.Join(escaped, "/"))
+	u.Path, _ = url.PathUnescape(u.RawPath)
+	return &u
+}
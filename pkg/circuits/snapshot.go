@@ -0,0 +1,128 @@
+package circuits
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/iden3/prover-server/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// snapshots keeps immutable copies of artifact files of loaded circuits, so that proofs use the files their
+// circuit was loaded with even if circuit files are changed or removed. Copies are named by SHA-256 hashes
+// of their content, a copy is removed when neither loaded circuit nor proof in progress refers to it.
+type snapshots struct {
+	dir string
+	// temporary is set if dir was created by the registry and is removed on close
+	temporary bool
+
+	mu   sync.Mutex
+	refs map[string]int
+}
+
+// newSnapshots creates store of snapshots in dir, temporary directory is used if dir is empty.
+// Files left in dir by previous runs are removed.
+func newSnapshots(dir string) (*snapshots, error) {
+	s := &snapshots{dir: dir, refs: make(map[string]int)}
+	if dir == "" {
+		var err error
+		if s.dir, err = os.MkdirTemp("", "circuit-snapshots-"); err != nil {
+			return nil, errors.Wrap(err, "failed to create snapshots directory")
+		}
+		s.temporary = true
+		return s, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.Wrap(err, "failed to create snapshots directory")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read snapshots directory")
+	}
+	for _, e := range entries {
+		if err = os.RemoveAll(filepath.Join(dir, e.Name())); err != nil {
+			return nil, errors.Wrap(err, "failed to clean snapshots directory")
+		}
+	}
+	return s, nil
+}
+
+// write copies file into temporary file of snapshot, content is also written to writers.
+// It returns path of temporary file, which is added by add, and size of the file.
+func (s *snapshots) write(fsys fs.FS, name string, writers ...io.Writer) (string, int64, error) {
+	src, err := fsys.Open(name)
+	if err != nil {
+		return "", 0, errors.Wrapf(err, "failed to read %s", name)
+	}
+	defer src.Close()
+
+	dst, err := os.CreateTemp(s.dir, ".snapshot-")
+	if err != nil {
+		return "", 0, errors.Wrap(err, "failed to create snapshot")
+	}
+	size, err := io.Copy(io.MultiWriter(append(writers, dst)...), src)
+	if closeErr := dst.Close(); err == nil && closeErr != nil {
+		err = errors.Wrap(closeErr, "failed to write snapshot")
+	}
+	if err != nil {
+		_ = os.Remove(dst.Name())
+		return "", 0, errors.Wrapf(err, "failed to read %s", name)
+	}
+	return dst.Name(), size, nil
+}
+
+// add moves written temporary file to snapshot named by hash of its content and refers to it,
+// the file is removed if the snapshot exists already
+func (s *snapshots) add(tmpPath, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.refs[name] > 0 {
+		s.refs[name]++
+		return errors.Wrap(os.Remove(tmpPath), "failed to remove snapshot")
+	}
+	if err := os.Rename(tmpPath, filepath.Join(s.dir, name)); err != nil {
+		return errors.Wrap(err, "failed to save snapshot")
+	}
+	s.refs[name] = 1
+	return nil
+}
+
+// acquire adds references to snapshots
+func (s *snapshots) acquire(names ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, name := range names {
+		if name != "" {
+			s.refs[name]++
+		}
+	}
+}
+
+// release removes references to snapshots, snapshots without references are removed
+func (s *snapshots) release(names ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, name := range names {
+		if name == "" || s.refs[name] == 0 {
+			continue
+		}
+		if s.refs[name]--; s.refs[name] == 0 {
+			delete(s.refs, name)
+			if err := os.Remove(filepath.Join(s.dir, name)); err != nil {
+				log.Errorw("failed to remove snapshot", "snapshot", name, "error", err)
+			}
+		}
+	}
+}
+
+// close removes temporary snapshots directory
+func (s *snapshots) close() error {
+	if !s.temporary {
+		return nil
+	}
+	return errors.Wrap(os.RemoveAll(s.dir), "failed to remove snapshots directory")
+}
@@ -0,0 +1,341 @@
+package circuits
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Names of files of upload
+const (
+	UploadWasm            = "wasm"
+	UploadZKey            = "zkey"
+	UploadVerificationKey = "vkey"
+	UploadManifest        = "manifest"
+	UploadInputs          = "inputs"
+)
+
+// uploadsDir is a hidden directory of circuits directory with files of uploads
+const uploadsDir = ".uploads"
+
+// uploadTTL is a period after which abandoned uploads are removed
+const uploadTTL = 24 * time.Hour
+
+var (
+	// ErrUploadNotFound is returned when there is no upload with the given id
+	ErrUploadNotFound = errors.New("upload doesn't exist")
+	// ErrUploadOffset is returned when chunk doesn't continue uploaded part of the file
+	ErrUploadOffset = errors.New("chunk offset is beyond uploaded size")
+	// ErrUploadFile is returned for unknown file of upload
+	ErrUploadFile = errors.New("unknown upload file")
+	// ErrInvalidUpload is returned when uploaded circuit is incomplete or fails validation
+	ErrInvalidUpload = errors.New("uploaded circuit is invalid")
+	// ErrUploadsDisabled is returned when circuits path isn't a directory circuits can be installed into
+	ErrUploadsDisabled = errors.New("circuit uploads require circuits directory")
+)
+
+// uploadFiles are names of files of upload
+var uploadFiles = map[string]bool{
+	UploadWasm:            true,
+	UploadZKey:            true,
+	UploadVerificationKey: true,
+	UploadManifest:        true,
+	UploadInputs:          true,
+}
+
+// Upload is a set of circuit files uploaded by chunks, so upload of large zkey can be resumed
+type Upload struct {
+	ID string `json:"id"`
+	// Files are sizes of uploaded parts of files
+	Files map[string]int64 `json:"files"`
+}
+
+// Uploads keeps files of uploads in hidden directory of circuits directory until they are installed
+type Uploads struct {
+	path string
+}
+
+// NewUploads creates uploads kept in path
+func NewUploads(path string) *Uploads {
+	return &Uploads{path: path}
+}
+
+// Create starts new upload, abandoned uploads are removed
+func (u *Uploads) Create() (Upload, error) {
+	u.removeExpired()
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return Upload{}, errors.Wrap(err, "failed to generate upload id")
+	}
+	id := hex.EncodeToString(b)
+	if err := os.MkdirAll(filepath.Join(u.path, id), 0o755); err != nil {
+		return Upload{}, errors.Wrap(err, "failed to create upload")
+	}
+	return Upload{ID: id, Files: map[string]int64{}}, nil
+}
+
+// Get returns upload with sizes of uploaded files
+func (u *Uploads) Get(id string) (Upload, error) {
+	uploadPath, err := u.uploadPath(id)
+	if err != nil {
+		return Upload{}, err
+	}
+	entries, err := os.ReadDir(uploadPath)
+	if err != nil {
+		return Upload{}, errors.Wrap(err, "failed to read upload")
+	}
+
+	upload := Upload{ID: id, Files: make(map[string]int64, len(entries))}
+	for _, e := range entries {
+		fi, err := e.Info()
+		if err != nil {
+			return Upload{}, errors.Wrap(err, "failed to read upload")
+		}
+		upload.Files[e.Name()] = fi.Size()
+	}
+	return upload, nil
+}
+
+// Write writes chunk of the file at offset, which can't be beyond uploaded size of the file.
+// Chunk at offset 0 replaces the file. It returns uploaded size of the file.
+func (u *Uploads) Write(id, file string, offset int64, chunk io.Reader) (int64, error) {
+	uploadPath, err := u.uploadPath(id)
+	if err != nil {
+		return 0, err
+	}
+	if !uploadFiles[file] {
+		return 0, ErrUploadFile
+	}
+
+	f, err := os.OpenFile(filepath.Join(uploadPath, file), os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to open upload file")
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to open upload file")
+	}
+	if offset > fi.Size() {
+		return fi.Size(), ErrUploadOffset
+	}
+
+	// data after offset is discarded, so failed chunk can be uploaded again
+	if err = f.Truncate(offset); err != nil {
+		return 0, errors.Wrap(err, "failed to write upload file")
+	}
+	if _, err = f.Seek(offset, io.SeekStart); err != nil {
+		return 0, errors.Wrap(err, "failed to write upload file")
+	}
+	n, err := io.Copy(f, chunk)
+	if err != nil {
+		return offset + n, errors.Wrap(err, "failed to write upload file")
+	}
+	return offset + n, errors.Wrap(f.Close(), "failed to write upload file")
+}
+
+// Remove removes upload with its files
+func (u *Uploads) Remove(id string) error {
+	uploadPath, err := u.uploadPath(id)
+	if err != nil {
+		return err
+	}
+	return errors.Wrap(os.RemoveAll(uploadPath), "failed to remove upload")
+}
+
+// uploadPath returns path of existing upload
+func (u *Uploads) uploadPath(id string) (string, error) {
+	if _, err := hex.DecodeString(id); err != nil || id == "" {
+		return "", ErrUploadNotFound
+	}
+	uploadPath := filepath.Join(u.path, id)
+	if _, err := os.Stat(uploadPath); err != nil {
+		return "", ErrUploadNotFound
+	}
+	return uploadPath, nil
+}
+
+// removeExpired removes uploads which weren't modified for uploadTTL
+func (u *Uploads) removeExpired() {
+	entries, err := os.ReadDir(u.path)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		uploadPath := filepath.Join(u.path, e.Name())
+		if modified, err := lastModified(uploadPath); err == nil && time.Since(modified) > uploadTTL {
+			_ = os.RemoveAll(uploadPath)
+		}
+	}
+}
+
+// lastModified returns the latest modification time of directory and its files
+func lastModified(dirPath string) (time.Time, error) {
+	fi, err := os.Stat(dirPath)
+	if err != nil {
+		return time.Time{}, err
+	}
+	modified := fi.ModTime()
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, e := range entries {
+		if fi, err := e.Info(); err == nil && fi.ModTime().After(modified) {
+			modified = fi.ModTime()
+		}
+	}
+	return modified, nil
+}
+
+// Install validates circuit from upload with test and publishes it into circuits directory as circuit directory
+// dir, existing directory is replaced. Files of upload are linked or copied, so upload stays intact and install
+// can be retried if it fails, the caller removes upload after successful install.
+func (r *Registry) Install(ctx context.Context, dir string, upload Upload,
+	test func(ctx context.Context, c Circuit) error) (Circuit, error) {
+
+	if r.uploads == nil {
+		return Circuit{}, ErrUploadsDisabled
+	}
+	if err := validateName(dir); err != nil {
+		return Circuit{}, err
+	}
+	// hidden directories aren't loaded
+	if strings.HasPrefix(dir, ".") {
+		return Circuit{}, ErrIllegalName
+	}
+	for _, f := range []string{UploadWasm, UploadZKey, UploadVerificationKey} {
+		if _, ok := upload.Files[f]; !ok {
+			return Circuit{}, errors.Wrapf(ErrInvalidUpload, "%s file is missing", f)
+		}
+	}
+	uploadPath, err := r.uploads.uploadPath(upload.ID)
+	if err != nil {
+		return Circuit{}, err
+	}
+	r.installMu.Lock()
+	defer r.installMu.Unlock()
+
+	// hidden directory isn't loaded by reload until it's published
+	tmp, err := os.MkdirTemp(r.basePath, "."+dir+"-")
+	if err != nil {
+		return Circuit{}, errors.Wrap(err, "failed to create circuit directory")
+	}
+	defer os.RemoveAll(tmp)
+	src := dirSource(r.basePath, filepath.Base(tmp))
+	src.dir = dir
+
+	if err = linkUploadFile(uploadPath, UploadManifest, tmp, ManifestFile); err != nil {
+		return Circuit{}, err
+	}
+	manifest, err := ReadManifest(src.fsys)
+	if err != nil {
+		return Circuit{}, errors.Wrap(ErrInvalidUpload, err.Error())
+	}
+	files, err := r.artifactFiles(dir, manifest)
+	if err != nil {
+		return Circuit{}, errors.Wrap(ErrInvalidUpload, err.Error())
+	}
+	for f, target := range map[string]string{
+		UploadWasm:            files.Wasm,
+		UploadZKey:            files.ZKey,
+		UploadVerificationKey: files.VerificationKey,
+		UploadInputs:          SelfTestInputsFile,
+	} {
+		if err = linkUploadFile(uploadPath, f, tmp, target); err != nil {
+			return Circuit{}, err
+		}
+	}
+
+	c, err := r.load(src, manifest, files, "")
+	if err != nil {
+		return Circuit{}, errors.Wrap(ErrInvalidUpload, err.Error())
+	}
+	// snapshots of tested circuit are shared with the installed one, which is loaded by reload
+	defer r.snapshots.release(c.Location.Files.List()...)
+	if err = test(ctx, *c); err != nil {
+		return Circuit{}, errors.Wrap(ErrInvalidUpload, errors.Wrap(err, "test proof failed").Error())
+	}
+
+	if err = r.publish(tmp, filepath.Join(r.basePath, dir)); err != nil {
+		return Circuit{}, err
+	}
+	if err = r.Reload(); err != nil {
+		return Circuit{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if loadErr, ok := r.loadErrors[dir]; ok {
+		return Circuit{}, errors.Errorf("installed circuit isn't loaded: %s", loadErr)
+	}
+	installed := *r.circuits[dir]
+	installed.Disabled = r.disabled[installed.ID()]
+	return installed, nil
+}
+
+// publish replaces circuit directory with the new one. Replaced directory is removed right away, proofs of
+// its circuit in progress or queued read snapshots of artifacts acquired when they were resolved.
+func (r *Registry) publish(newPath, circuitPath string) error {
+	backup := ""
+	if _, err := os.Stat(circuitPath); err == nil {
+		backup = filepath.Join(r.basePath, "."+filepath.Base(newPath)+"-replaced")
+		if err = os.Rename(circuitPath, backup); err != nil {
+			return errors.Wrap(err, "failed to replace circuit directory")
+		}
+	}
+	if err := os.Rename(newPath, circuitPath); err != nil {
+		if backup != "" {
+			_ = os.Rename(backup, circuitPath)
+		}
+		return errors.Wrap(err, "failed to publish circuit directory")
+	}
+	if backup != "" {
+		_ = os.RemoveAll(backup)
+	}
+	return nil
+}
+
+// linkUploadFile hard-links file of upload into circuit directory if it was uploaded,
+// file is copied if it can't be linked
+func linkUploadFile(uploadPath, file, circuitPath, target string) error {
+	filePath := filepath.Join(circuitPath, filepath.FromSlash(target))
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return errors.Wrap(err, "failed to create circuit directory")
+	}
+	uploadedPath := filepath.Join(uploadPath, file)
+	if _, err := os.Stat(uploadedPath); os.IsNotExist(err) {
+		return nil
+	}
+	if err := os.Link(uploadedPath, filePath); err == nil {
+		return nil
+	}
+	return errors.Wrapf(copyFile(uploadedPath, filePath), "failed to copy %s file", file)
+}
+
+// copyFile copies content of file into a new file
+func copyFile(from, to string) error {
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(dst, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	return dst.Close()
+}
@@ -0,0 +1,130 @@
+package circuits
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/iden3/prover-server/pkg/app/configs"
+	"github.com/iden3/prover-server/pkg/circuits/circuitstest"
+	"github.com/iden3/prover-server/pkg/proof"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadsResumeChunks(t *testing.T) {
+	uploads := NewUploads(t.TempDir())
+	upload, err := uploads.Create()
+	require.NoError(t, err)
+
+	size, err := uploads.Write(upload.ID, UploadZKey, 0, strings.NewReader("zkey"))
+	require.NoError(t, err)
+	require.EqualValues(t, 4, size)
+	_, err = uploads.Write(upload.ID, UploadZKey, 6, strings.NewReader("\x00"))
+	require.ErrorIs(t, err, ErrUploadOffset)
+	// chunk is written again after failure
+	_, err = uploads.Write(upload.ID, UploadZKey, 4, strings.NewReader("\x02"))
+	require.NoError(t, err)
+	size, err = uploads.Write(upload.ID, UploadZKey, 4, strings.NewReader("\x01\x00\x00\x00"))
+	require.NoError(t, err)
+	require.EqualValues(t, 8, size)
+
+	_, err = uploads.Write(upload.ID, "circuit.wasm", 0, strings.NewReader("wasm"))
+	require.ErrorIs(t, err, ErrUploadFile)
+	_, err = uploads.Get("unknown")
+	require.ErrorIs(t, err, ErrUploadNotFound)
+
+	upload, err = uploads.Get(upload.ID)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{UploadZKey: 8}, upload.Files)
+	require.NoError(t, uploads.Remove(upload.ID))
+	_, err = uploads.Get(upload.ID)
+	require.ErrorIs(t, err, ErrUploadNotFound)
+}
+
+func TestRegistryInstallsUploadedCircuit(t *testing.T) {
+	basePath := t.TempDir()
+	circuitstest.WriteCircuit(t, basePath, "auth")
+	r, err := NewRegistry(configs.ProverConfig{CircuitsBasePath: basePath})
+	require.NoError(t, err)
+	defer r.Close()
+
+	zkey := circuitstest.Artifacts[proof.ZKeyFile]
+	upload := func(manifest string) Upload {
+		upload, err := r.Uploads().Create()
+		require.NoError(t, err)
+		files := map[string][]byte{
+			UploadWasm:            circuitstest.Artifacts[proof.WasmFile],
+			UploadZKey:            zkey,
+			UploadVerificationKey: circuitstest.Artifacts[proof.VerificationKeyFile],
+			UploadInputs:          []byte("{}"),
+		}
+		if manifest != "" {
+			files[UploadManifest] = []byte(manifest)
+		}
+		for f, content := range files {
+			_, err = r.Uploads().Write(upload.ID, f, 0, bytes.NewReader(content))
+			require.NoError(t, err)
+		}
+		upload, err = r.Uploads().Get(upload.ID)
+		require.NoError(t, err)
+		return upload
+	}
+	passed := func(context.Context, Circuit) error { return nil }
+
+	// circuit which fails test proof isn't published
+	failed := upload("")
+	_, err = r.Install(context.Background(), "authV2", failed, func(context.Context, Circuit) error {
+		return errors.New("failed to verify proof")
+	})
+	require.ErrorIs(t, err, ErrInvalidUpload)
+	_, err = os.Stat(filepath.Join(basePath, "authV2"))
+	require.True(t, os.IsNotExist(err))
+
+	// upload isn't consumed by failed install, so install can be retried
+	c, err := r.Install(context.Background(), "authV2", failed, passed)
+	require.NoError(t, err)
+	require.Equal(t, "authV2", c.ID())
+	retried, err := r.Uploads().Get(failed.ID)
+	require.NoError(t, err)
+	require.Equal(t, failed.Files, retried.Files)
+
+	zkey = []byte("zkey\x03\x00\x00\x00")
+	c, err = r.Install(context.Background(), "authV2", upload("name: auth\nversion: 2.0.0\n"), passed)
+	require.NoError(t, err)
+	require.Equal(t, "auth@2.0.0", c.ID())
+	_, err = r.Get("auth@latest")
+	require.NoError(t, err)
+	inputs, err := os.ReadFile(filepath.Join(basePath, "authV2", SelfTestInputsFile))
+	require.NoError(t, err)
+	require.Equal(t, "{}", string(inputs))
+
+	// existing circuit is replaced, proof of replaced version finishes with its files
+	replaced, release, err := r.Acquire(context.Background(), "auth@2.0.0")
+	require.NoError(t, err)
+	replacedZKey := zkey
+	zkey = []byte("zkey\x02\x00\x00\x00")
+	c, err = r.Install(context.Background(), "authV2", upload("name: auth\nversion: 2.1.0\n"), passed)
+	require.NoError(t, err)
+	require.Equal(t, "auth@2.1.0", c.ID())
+	_, err = r.Get("auth@2.0.0")
+	require.ErrorIs(t, err, ErrNotFound)
+	require.Equal(t, replacedZKey, readArtifacts(t, r, replaced).ZKey)
+	require.Equal(t, zkey, readArtifacts(t, r, c).ZKey)
+	release()
+	_, err = os.Stat(filepath.Join(replaced.Location.Path, replaced.Location.Files.ZKey))
+	require.True(t, os.IsNotExist(err))
+
+	_, err = r.Install(context.Background(), ".uploads", upload(""), passed)
+	require.ErrorIs(t, err, ErrIllegalName)
+	incomplete := upload("")
+	delete(incomplete.Files, UploadZKey)
+	_, err = r.Install(context.Background(), "incomplete", incomplete, passed)
+	require.ErrorIs(t, err, ErrInvalidUpload)
+
+	require.Len(t, r.List(), 2)
+	require.Empty(t, r.LoadErrors())
+}
@@ -0,0 +1,84 @@
+package circuits
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/iden3/prover-server/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// Watch reloads circuits when files in circuits directory change until ctx is done. Reload starts after
+// there were no changes for debounce period, so files that are still being copied are not loaded.
+func (r *Registry) Watch(ctx context.Context, debounce time.Duration) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.Wrap(err, "failed to create circuits watcher")
+	}
+	if err = r.watchDirs(watcher); err != nil {
+		_ = watcher.Close()
+		return err
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		timer := time.NewTimer(debounce)
+		timer.Stop()
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				log.Debugw("Circuits directory changed", "file", event.Name, "op", event.Op.String())
+				timer.Reset(debounce)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Errorw("circuits watcher error", "error", err)
+			case <-timer.C:
+				if err := r.Reload(); err != nil {
+					log.Errorw("failed to reload circuits", "error", err)
+				}
+				// watch directories of new circuits
+				if err := r.watchDirs(watcher); err != nil {
+					log.Errorw("failed to watch circuits", "error", err)
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// watchDirs adds circuits directory and all its subdirectories to the watcher, as watching isn't recursive
+// and artifacts may be located in nested directories of circuit. Directory of archive is watched
+// if circuits path is an archive.
+func (r *Registry) watchDirs(watcher *fsnotify.Watcher) error {
+	if archiveExtension(r.basePath) != "" {
+		if fi, err := os.Stat(r.basePath); err == nil && !fi.IsDir() {
+			return errors.Wrap(watcher.Add(filepath.Dir(r.basePath)), "failed to watch circuits archive")
+		}
+	}
+
+	err := filepath.WalkDir(r.basePath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		return errors.Wrapf(watcher.Add(path), "failed to watch %s", path)
+	})
+	return errors.Wrap(err, "failed to watch circuits directory")
+}
@@ -0,0 +1,307 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iden3/go-rapidsnark/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultMaxRetries = 3
+	defaultRetryWait  = time.Second
+	defaultMaxWait    = time.Minute
+)
+
+// Codes of error responses of prover server
+const (
+	// ErrCodeProofTimeout is a code of error when proof generation exceeds timeout of the circuit
+	ErrCodeProofTimeout = 1
+	// ErrCodeCircuitNotFound is a code of error when server doesn't have the circuit
+	ErrCodeCircuitNotFound = 2
+)
+
+// Inputs are circuit specific inputs for proof generation
+type Inputs map[string]interface{}
+
+// GenerateRequest is request for proof generation
+type GenerateRequest struct {
+	CircuitName string `json:"circuit_name"`
+	Inputs      Inputs `json:"inputs"`
+}
+
+// BatchResult is result of a single proof generation in the batch, either Proof or Error is set
+type BatchResult struct {
+	Proof *types.ZKProof `json:"proof,omitempty"`
+	Error string         `json:"error,omitempty"`
+}
+
+// Error is error response of the server
+type Error struct {
+	StatusCode int    `json:"-"`
+	Code       int    `json:"code"`
+	Message    string `json:"error"`
+	Details    string `json:"details"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("prover server responded with status %d: %s (%s)", e.StatusCode, e.Message, e.Details)
+}
+
+type priorityKey struct{}
+
+// WithPriority returns context of requests sent with X-Priority header, e.g. "high", "normal" or "low"
+func WithPriority(ctx context.Context, priority string) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// Client calls prover server API
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	headers    http.Header
+	maxRetries int
+	retryWait  time.Duration
+	maxWait    time.Duration
+}
+
+// Option configures the Client
+type Option func(c *Client)
+
+// WithHTTPClient sets HTTP client used for requests
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithHeader adds header to every request, e.g. for authorization
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.headers.Add(key, value)
+	}
+}
+
+// WithRetries sets how many times requests rejected with 429 or 503 are retried and how long to wait
+// between attempts if server didn't send Retry-After header
+func WithRetries(maxRetries int, wait time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.retryWait = wait
+	}
+}
+
+// WithMaxRetryWait limits wait time requested by server in Retry-After header
+func WithMaxRetryWait(maxWait time.Duration) Option {
+	return func(c *Client) {
+		c.maxWait = maxWait
+	}
+}
+
+// New creates client of the prover server with base URL like http://localhost:8002
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		headers:    make(http.Header),
+		maxRetries: defaultMaxRetries,
+		retryWait:  defaultRetryWait,
+		maxWait:    defaultMaxWait,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Generate generates proof for the circuit. Request is sent with idempotency key, so retries don't
+// start new proof generation.
+func (c *Client) Generate(ctx context.Context, circuitName string, inputs Inputs) (*types.ZKProof, error) {
+	key, err := newIdempotencyKey()
+	if err != nil {
+		return nil, err
+	}
+
+	var zkp types.ZKProof
+	err = c.do(ctx, http.MethodPost, "/api/v1/proof/generate",
+		GenerateRequest{CircuitName: circuitName, Inputs: inputs}, &zkp, http.Header{"Idempotency-Key": {key}})
+	if err != nil {
+		return nil, err
+	}
+	return &zkp, nil
+}
+
+// Verify verifies proof with verification key of the circuit
+func (c *Client) Verify(ctx context.Context, circuitName string, zkp *types.ZKProof) (bool, error) {
+	req := struct {
+		CircuitName string         `json:"circuit_name"`
+		ZKP         *types.ZKProof `json:"zkp"`
+	}{circuitName, zkp}
+
+	var resp struct {
+		Valid bool `json:"valid"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/api/v1/proof/verify", req, &resp, nil); err != nil {
+		return false, err
+	}
+	return resp.Valid, nil
+}
+
+// GenerateBatch generates proofs for several requests, results are in the order of requests
+func (c *Client) GenerateBatch(ctx context.Context, reqs []GenerateRequest) ([]BatchResult, error) {
+	req := struct {
+		Requests []GenerateRequest `json:"requests"`
+	}{reqs}
+
+	var resp struct {
+		Results []BatchResult `json:"results"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/api/v1/proof/generate/batch", req, &resp, nil); err != nil {
+		return nil, err
+	}
+	return resp.Results, nil
+}
+
+// ListCircuits returns names of circuits available on the server
+func (c *Client) ListCircuits(ctx context.Context) ([]string, error) {
+	var resp struct {
+		Circuits []struct {
+			Name string `json:"name"`
+		} `json:"circuits"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/v1/circuits", nil, &resp, nil); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(resp.Circuits))
+	for i := range resp.Circuits {
+		names[i] = resp.Circuits[i].Name
+	}
+	return names, nil
+}
+
+// do sends request retrying it if server is overloaded or unavailable and decodes JSON response into out
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}, headers http.Header) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return errors.Wrap(err, "failed to serialize request")
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, method, path, body, headers)
+		if err != nil {
+			return err
+		}
+
+		if (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable) &&
+			attempt < c.maxRetries {
+			wait := c.retryAfter(resp, attempt)
+			drain(resp)
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+			continue
+		}
+
+		return decodeResponse(resp, out)
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, path string, body []byte, headers http.Header) (*http.Response, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bodyReader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range c.headers {
+		req.Header[k] = v
+	}
+	for k, v := range headers {
+		req.Header[k] = v
+	}
+	if priority, ok := ctx.Value(priorityKey{}).(string); ok && priority != "" {
+		req.Header.Set("X-Priority", priority)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to send request")
+	}
+	return resp, nil
+}
+
+// retryAfter returns wait time requested by server or exponential backoff if it's not set
+func (c *Client) retryAfter(resp *http.Response, attempt int) time.Duration {
+	wait := c.retryWait << attempt
+
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil {
+			wait = time.Duration(seconds) * time.Second
+		} else if t, err := http.ParseTime(v); err == nil {
+			wait = time.Until(t)
+		}
+	}
+
+	if wait < 0 {
+		wait = 0
+	}
+	if wait > c.maxWait {
+		wait = c.maxWait
+	}
+	return wait
+}
+
+func decodeResponse(resp *http.Response, out interface{}) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &Error{StatusCode: resp.StatusCode}
+		if err := json.NewDecoder(resp.Body).Decode(apiErr); err != nil {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return apiErr
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return errors.Wrap(err, "failed to parse response")
+	}
+	return nil
+}
+
+func drain(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+}
+
+func newIdempotencyKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "failed to generate idempotency key")
+	}
+	return hex.EncodeToString(b), nil
+}
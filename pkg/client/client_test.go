@@ -0,0 +1,223 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/iden3/go-rapidsnark/types"
+	"github.com/iden3/go-rapidsnark/verifier"
+	"github.com/iden3/prover-server/pkg/app"
+	"github.com/iden3/prover-server/pkg/app/configs"
+	"github.com/iden3/prover-server/pkg/app/handlers"
+	"github.com/iden3/prover-server/pkg/circuits"
+	"github.com/iden3/prover-server/pkg/circuits/circuitstest"
+	"github.com/iden3/prover-server/pkg/client"
+	"github.com/iden3/prover-server/pkg/idempotency"
+	"github.com/iden3/prover-server/pkg/proof"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	circuitsPath := t.TempDir()
+	circuitstest.WriteCircuit(t, circuitsPath, "auth")
+	registry, err := circuits.NewRegistry(configs.ProverConfig{CircuitsBasePath: circuitsPath})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = registry.Close() })
+
+	appHandlers := app.Handlers{
+		ZKHandler: handlers.NewZKHandler(registry, nil),
+	}
+	srv := httptest.NewServer(appHandlers.Routes())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newProofServer starts server generating real proofs of multiplier circuit with gnark prover and wazero,
+// it returns the server and verification key of the circuit
+func newProofServer(t *testing.T) (*httptest.Server, []byte) {
+	circuitsPath := t.TempDir()
+	circuitPath := circuitstest.WriteMultiplier(t, circuitsPath, "multiplier")
+	registry, err := circuits.NewRegistry(configs.ProverConfig{
+		CircuitsBasePath: circuitsPath,
+		Backend:          configs.ProverBackendConfig{Type: proof.BackendGnark},
+		Witness:          configs.WitnessConfig{Type: proof.WitnessWazero},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = registry.Close() })
+	vkey, err := os.ReadFile(filepath.Join(circuitPath, proof.VerificationKeyFile))
+	require.NoError(t, err)
+
+	appHandlers := app.Handlers{
+		ZKHandler: handlers.NewZKHandler(registry, idempotency.NewStore(time.Minute)),
+	}
+	srv := httptest.NewServer(appHandlers.Routes())
+	t.Cleanup(srv.Close)
+	return srv, vkey
+}
+
+func TestGenerateAndVerify(t *testing.T) {
+	srv, vkey := newProofServer(t)
+	target, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	upstream := httputil.NewSingleHostReverseProxy(target)
+
+	// stand-in loses response of the first proof, so that the client retries the request
+	var mu sync.Mutex
+	var keys []string
+	var lost []byte
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/proof/generate" {
+			upstream.ServeHTTP(w, r)
+			return
+		}
+		mu.Lock()
+		keys = append(keys, r.Header.Get(idempotency.HeaderKey))
+		retried := len(keys) > 1
+		mu.Unlock()
+		if retried {
+			upstream.ServeHTTP(w, r)
+			return
+		}
+		rec := httptest.NewRecorder()
+		upstream.ServeHTTP(rec, r)
+		mu.Lock()
+		lost = rec.Body.Bytes()
+		mu.Unlock()
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer proxy.Close()
+
+	c := client.New(proxy.URL, client.WithRetries(1, time.Millisecond))
+	zkp, err := c.Generate(context.Background(), "multiplier", client.Inputs{"x": 3, "y": 5})
+	require.NoError(t, err)
+	require.Equal(t, []string{"15", "8"}, zkp.PubSignals)
+	require.NoError(t, verifier.VerifyGroth16(*zkp, vkey))
+
+	// retried request is sent with the same idempotency key and returns the proof of the first one
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, keys, 2)
+	require.NotEmpty(t, keys[0])
+	require.Equal(t, keys[0], keys[1])
+	var first types.ZKProof
+	require.NoError(t, json.Unmarshal(lost, &first))
+	require.Equal(t, first, *zkp)
+
+	valid, err := c.Verify(context.Background(), "multiplier", zkp)
+	require.NoError(t, err)
+	require.True(t, valid)
+	zkp.PubSignals = []string{"16", "8"}
+	valid, err = c.Verify(context.Background(), "multiplier", zkp)
+	require.NoError(t, err)
+	require.False(t, valid)
+}
+
+func TestGenerateBatchProofs(t *testing.T) {
+	srv, vkey := newProofServer(t)
+	c := client.New(srv.URL)
+
+	results, err := c.GenerateBatch(context.Background(), []client.GenerateRequest{
+		{CircuitName: "multiplier", Inputs: client.Inputs{"x": 3, "y": 5}},
+		{CircuitName: "multiplier", Inputs: client.Inputs{"x": 2, "y": 7}},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	for i, signals := range [][]string{{"15", "8"}, {"14", "9"}} {
+		require.Empty(t, results[i].Error)
+		require.Equal(t, signals, results[i].Proof.PubSignals)
+		require.NoError(t, verifier.VerifyGroth16(*results[i].Proof, vkey))
+	}
+}
+
+func TestListCircuits(t *testing.T) {
+	srv := newTestServer(t)
+	c := client.New(srv.URL)
+
+	names, err := c.ListCircuits(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"auth"}, names)
+}
+
+func TestGenerateUnknownCircuit(t *testing.T) {
+	srv := newTestServer(t)
+	c := client.New(srv.URL)
+
+	_, err := c.Generate(context.Background(), "unknown", client.Inputs{"in": "1"})
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	require.Equal(t, "circuitPath doesn't exist", apiErr.Message)
+}
+
+func TestVerifyIllegalCircuit(t *testing.T) {
+	srv := newTestServer(t)
+	c := client.New(srv.URL)
+
+	_, err := c.Verify(context.Background(), "../auth", &types.ZKProof{})
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+}
+
+func TestGenerateBatch(t *testing.T) {
+	srv := newTestServer(t)
+	c := client.New(srv.URL)
+
+	results, err := c.GenerateBatch(context.Background(), []client.GenerateRequest{
+		{CircuitName: "unknown1", Inputs: client.Inputs{"in": "1"}},
+		{CircuitName: "unknown2", Inputs: client.Inputs{"in": "2"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	for _, res := range results {
+		require.Nil(t, res.Proof)
+		require.Equal(t, "circuitPath doesn't exist", res.Error)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	srv := newTestServer(t)
+
+	target, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	upstream := httputil.NewSingleHostReverseProxy(target)
+
+	// stand-in that is unavailable for the first two requests
+	var calls int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			upstream.ServeHTTP(w, r)
+		}
+	}))
+	defer proxy.Close()
+
+	c := client.New(proxy.URL, client.WithRetries(2, time.Millisecond))
+	names, err := c.ListCircuits(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"auth"}, names)
+	require.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	atomic.StoreInt32(&calls, 0)
+	c = client.New(proxy.URL, client.WithRetries(1, time.Millisecond))
+	_, err = c.ListCircuits(context.Background())
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusTooManyRequests, apiErr.StatusCode)
+}
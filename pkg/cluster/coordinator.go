@@ -0,0 +1,391 @@
+package cluster
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/iden3/go-rapidsnark/types"
+	"github.com/iden3/prover-server/pkg/app/configs"
+	"github.com/iden3/prover-server/pkg/client"
+	"github.com/iden3/prover-server/pkg/log"
+	"github.com/iden3/prover-server/pkg/proof"
+	"github.com/iden3/prover-server/pkg/scheduler"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultHeartbeatInterval = 5 * time.Second
+	defaultRetries           = 2
+)
+
+// ErrNoWorkers is returned when no live worker has the circuit loaded
+var ErrNoWorkers = errors.New("no workers available for the circuit")
+
+// Heartbeat is sent by workers to register with the coordinator and to report circuits they have loaded
+type Heartbeat struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+	// Circuits are IDs (name@version or name) of enabled circuits of the worker
+	Circuits []string `json:"circuits"`
+	Capacity int      `json:"capacity,omitempty"`
+}
+
+// WorkerStatus is a state of registered worker
+type WorkerStatus struct {
+	ID       string    `json:"id"`
+	URL      string    `json:"url"`
+	Circuits []string  `json:"circuits"`
+	Capacity int       `json:"capacity"`
+	InFlight int       `json:"in_flight"`
+	LastSeen time.Time `json:"last_seen"`
+	// Failed is set when request to the worker failed, worker isn't used until its next heartbeat
+	Failed bool `json:"failed,omitempty"`
+}
+
+// VerifyFunc verifies proof returned by worker
+type VerifyFunc func(zkp *types.ZKProof) error
+
+// Coordinator dispatches proofs to workers registered by heartbeats. Proof is sent to the least loaded live worker
+// that has the circuit loaded, and it's retried on other workers if the worker fails.
+type Coordinator struct {
+	mu      sync.Mutex
+	workers map[string]*member
+	timeout time.Duration
+	retries int
+	now     func() time.Time
+	// waiting are numbers of requests waiting for workers by circuit and priority rank
+	waiting map[waitKey]int
+	// changed is closed when capacity of workers changes
+	changed chan struct{}
+	// clientOptions configure clients of workers: client certificate and API key the coordinator is known by
+	clientOptions []client.Option
+}
+
+// member is a registered worker
+type member struct {
+	WorkerStatus
+	circuits map[string]bool
+	client   *client.Client
+	// dispatched is a time of the last request, it spreads requests among equally loaded workers
+	dispatched time.Time
+}
+
+// waitKey identifies requests waiting for workers with the circuit in the lane of priority rank
+type waitKey struct {
+	circuit string
+	rank    int
+}
+
+// NewCoordinator creates coordinator with settings of heartbeats, retries and credentials of requests to workers
+// from config
+func NewCoordinator(config configs.ClusterConfig) (*Coordinator, error) {
+	c := &Coordinator{
+		workers:       make(map[string]*member),
+		waiting:       make(map[waitKey]int),
+		changed:       make(chan struct{}),
+		timeout:       config.HeartbeatTimeout,
+		retries:       config.Retries,
+		now:           time.Now,
+		clientOptions: []client.Option{client.WithRetries(0, 0)},
+	}
+	httpClient, err := newWorkersHTTPClient(config)
+	if err != nil {
+		return nil, err
+	}
+	if httpClient != nil {
+		c.clientOptions = append(c.clientOptions, client.WithHTTPClient(httpClient))
+	}
+	if config.APIKey != "" {
+		c.clientOptions = append(c.clientOptions, client.WithHeader(scheduler.HeaderAPIKey, config.APIKey))
+	}
+	if c.timeout <= 0 {
+		interval := config.HeartbeatInterval
+		if interval <= 0 {
+			interval = defaultHeartbeatInterval
+		}
+		c.timeout = 3 * interval
+	}
+	if c.retries <= 0 {
+		c.retries = defaultRetries
+	}
+	return c, nil
+}
+
+// newWorkersHTTPClient returns HTTP client with client certificate and CA bundle of workers from config,
+// nil is returned if neither is set
+func newWorkersHTTPClient(config configs.ClusterConfig) (*http.Client, error) {
+	if config.ClientCertFile == "" && config.ClientKeyFile == "" && config.CAFile == "" {
+		return nil, nil
+	}
+	if (config.ClientCertFile == "") != (config.ClientKeyFile == "") {
+		return nil, errors.New("cluster.clientCertFile and cluster.clientKeyFile must be set together")
+	}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if config.ClientCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.ClientCertFile, config.ClientKeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load client certificate of coordinator")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if config.CAFile != "" {
+		pem, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read CA bundle of workers")
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificates found in CA bundle of workers")
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &http.Client{Transport: transport}, nil
+}
+
+// Heartbeat registers worker or refreshes its circuits
+func (c *Coordinator) Heartbeat(hb Heartbeat) error {
+	if hb.URL == "" {
+		return errors.New("worker URL is required")
+	}
+	u, err := url.Parse(hb.URL)
+	if err != nil {
+		return errors.Wrap(err, "illegal worker URL")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Hostname() == "" || u.User != nil {
+		return errors.Errorf("illegal worker URL %q, http(s) URL with host is required", hb.URL)
+	}
+	if hb.ID == "" {
+		hb.ID = hb.URL
+	}
+	if hb.Capacity <= 0 {
+		hb.Capacity = 1
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m, ok := c.workers[hb.ID]
+	if !ok || m.URL != hb.URL {
+		m = &member{client: client.New(hb.URL, c.clientOptions...)}
+		c.workers[hb.ID] = m
+		log.Infow("Worker registered", "worker", hb.ID, "url", hb.URL, "circuits", hb.Circuits)
+	}
+	m.ID, m.URL, m.Capacity = hb.ID, hb.URL, hb.Capacity
+	m.Circuits = hb.Circuits
+	m.circuits = make(map[string]bool, len(hb.Circuits))
+	for _, id := range hb.Circuits {
+		m.circuits[id] = true
+	}
+	m.LastSeen = c.now()
+	m.Failed = false
+	c.notify()
+	return nil
+}
+
+// Workers returns registered workers ordered by ID
+func (c *Coordinator) Workers() []WorkerStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expire()
+	list := make([]WorkerStatus, 0, len(c.workers))
+	for _, m := range c.workers {
+		list = append(list, m.WorkerStatus)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// Generate generates proof of the circuit on a worker, the proof is verified with verify before it's returned.
+// Worker gets at most its capacity of proofs, request waits until a worker with the circuit is free, requests of higher
+// priority are dispatched first. Timeout (0 is no timeout) starts when the request is dispatched, so that waiting
+// for workers doesn't count. Request is retried on other workers if worker is unreachable, doesn't have the circuit
+// or returns invalid proof.
+func (c *Coordinator) Generate(ctx context.Context, circuitID string, inputs proof.ZKInputs, timeout time.Duration,
+	verify VerifyFunc) (*types.ZKProof, error) {
+
+	p, ok := scheduler.PriorityFromContext(ctx)
+	if ok {
+		ctx = client.WithPriority(ctx, string(p))
+	}
+	tried := make(map[string]bool)
+	var lastErr error
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		m, err := c.acquire(ctx, circuitID, p.Rank(), tried)
+		if err != nil {
+			return nil, err
+		}
+		if m == nil {
+			break
+		}
+		tried[m.ID] = true
+		if attempt == 0 && timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		zkp, err := m.client.Generate(ctx, circuitID, client.Inputs(inputs))
+		if err == nil {
+			if err = verify(zkp); err != nil {
+				err = errors.Wrap(err, "worker returned invalid proof")
+			}
+		}
+		c.release(m, circuitID, err)
+		if err == nil {
+			return zkp, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		err = errors.Wrapf(err, "worker %s", m.ID)
+		if !retryable(err) {
+			return nil, err
+		}
+		log.WithContext(ctx).Warnw("Worker failed, retrying on another worker", "circuit", circuitID, "error", err)
+		lastErr = err
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, errors.Wrapf(ErrNoWorkers, "circuit %s", circuitID)
+}
+
+// acquire waits until a live worker with the circuit which isn't tried yet has free capacity and returns the least
+// loaded one, request waits while requests of higher priority rank wait for the circuit. Nil is returned if there
+// is no such worker.
+func (c *Coordinator) acquire(ctx context.Context, circuitID string, rank int, tried map[string]bool) (*member, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := waitKey{circuit: circuitID, rank: rank}
+	queued := false
+	defer func() {
+		if queued {
+			if c.waiting[key]--; c.waiting[key] == 0 {
+				delete(c.waiting, key)
+			}
+			// requests of lower priority may proceed now
+			c.notify()
+		}
+	}()
+	for {
+		c.expire()
+		best, found := c.pick(circuitID, tried)
+		if !found {
+			return nil, nil
+		}
+		if best != nil && !c.preceded(key) {
+			best.InFlight++
+			best.dispatched = c.now()
+			return best, nil
+		}
+		if !queued {
+			c.waiting[key]++
+			queued = true
+		}
+
+		changed := c.changed
+		c.mu.Unlock()
+		// workers which stopped sending heartbeats are expired on the next check
+		timer := time.NewTimer(c.timeout)
+		select {
+		case <-ctx.Done():
+		case <-changed:
+		case <-timer.C:
+		}
+		timer.Stop()
+		c.mu.Lock()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// pick returns the least loaded live worker with the circuit which isn't tried yet and has free capacity.
+// False is returned if there is no such worker regardless of its load.
+func (c *Coordinator) pick(circuitID string, tried map[string]bool) (*member, bool) {
+	var best *member
+	found := false
+	for _, m := range c.workers {
+		if m.Failed || tried[m.ID] || !m.circuits[circuitID] {
+			continue
+		}
+		found = true
+		if m.InFlight >= m.Capacity {
+			continue
+		}
+		if best == nil || m.InFlight*best.Capacity < best.InFlight*m.Capacity ||
+			m.InFlight*best.Capacity == best.InFlight*m.Capacity && m.dispatched.Before(best.dispatched) {
+			best = m
+		}
+	}
+	return best, found
+}
+
+// preceded returns true if requests of higher priority wait for the circuit
+func (c *Coordinator) preceded(key waitKey) bool {
+	for rank := 0; rank < key.rank; rank++ {
+		if c.waiting[waitKey{circuit: key.circuit, rank: rank}] > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// notify wakes up requests waiting for workers
+func (c *Coordinator) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// release updates state of the worker after request: unreachable worker is excluded until its next heartbeat,
+// worker without the circuit doesn't get it until its next heartbeat
+func (c *Coordinator) release(m *member, circuitID string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m.InFlight--
+	defer c.notify()
+	var apiErr *client.Error
+	switch {
+	case err == nil:
+	case errors.As(err, &apiErr):
+		if apiErr.Code == client.ErrCodeCircuitNotFound || apiErr.StatusCode == http.StatusServiceUnavailable {
+			delete(m.circuits, circuitID)
+		}
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+	default:
+		m.Failed = true
+	}
+}
+
+// expire removes workers which haven't sent heartbeats within timeout
+func (c *Coordinator) expire() {
+	for id, m := range c.workers {
+		if c.now().Sub(m.LastSeen) > c.timeout {
+			delete(c.workers, id)
+			log.Warnw("Worker removed after missed heartbeats", "worker", id)
+		}
+	}
+}
+
+// retryable returns true if request can succeed on another worker: worker is unreachable, it doesn't have
+// the circuit or returned invalid proof. Errors of proof generation, e.g. illegal inputs, aren't retried.
+func retryable(err error) bool {
+	var apiErr *client.Error
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.Code == client.ErrCodeCircuitNotFound:
+			return true
+		case apiErr.StatusCode == http.StatusBadGateway, apiErr.StatusCode == http.StatusServiceUnavailable:
+			return true
+		}
+		return false
+	}
+	return true
+}
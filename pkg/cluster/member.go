@@ -0,0 +1,106 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/iden3/prover-server/pkg/app/configs"
+	"github.com/iden3/prover-server/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// HeartbeatPath is a path of coordinator API workers send heartbeats to
+const HeartbeatPath = "/api/v1/cluster/heartbeat"
+
+// CircuitsFunc returns IDs of enabled circuits of the worker
+type CircuitsFunc func() ([]string, error)
+
+// Member registers the server as worker with the coordinator and reports its circuits by heartbeats
+type Member struct {
+	coordinatorURL string
+	token          string
+	interval       time.Duration
+	heartbeat      Heartbeat
+	circuits       CircuitsFunc
+	httpClient     *http.Client
+}
+
+// NewMember creates member of the cluster from config, AdvertiseURL is required
+func NewMember(config configs.ClusterConfig, circuits CircuitsFunc) (*Member, error) {
+	if config.AdvertiseURL == "" {
+		return nil, errors.New("cluster.advertiseURL is required to register with coordinator")
+	}
+	m := &Member{
+		coordinatorURL: strings.TrimRight(config.CoordinatorURL, "/"),
+		token:          config.Token,
+		interval:       config.HeartbeatInterval,
+		heartbeat: Heartbeat{
+			ID:       config.ID,
+			URL:      config.AdvertiseURL,
+			Capacity: config.Capacity,
+		},
+		circuits:   circuits,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+	if m.interval <= 0 {
+		m.interval = defaultHeartbeatInterval
+	}
+	return m, nil
+}
+
+// Run sends heartbeats until context is done, failed heartbeats are logged and retried on the next tick
+func (m *Member) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	registered := false
+	for {
+		err := m.send(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Errorw("cannot send heartbeat to coordinator", "coordinator", m.coordinatorURL, "error", err)
+		} else if err == nil && !registered {
+			log.Infow("Registered with coordinator", "coordinator", m.coordinatorURL)
+		}
+		registered = err == nil
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// send sends heartbeat with current circuits of the worker
+func (m *Member) send(ctx context.Context) error {
+	hb := m.heartbeat
+	var err error
+	if hb.Circuits, err = m.circuits(); err != nil {
+		return errors.Wrap(err, "failed to list circuits")
+	}
+	body, err := json.Marshal(hb)
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize heartbeat")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.coordinatorURL+HeartbeatPath, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.token != "" {
+		req.Header.Set("Authorization", "Bearer "+m.token)
+	}
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to send heartbeat")
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("coordinator responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package idempotency
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// HeaderKey is a name of the header clients use to pass idempotency key
+const HeaderKey = "Idempotency-Key"
+
+// HeaderReplayed is set on responses that were returned from the store instead of being computed
+const HeaderReplayed = "Idempotent-Replayed"
+
+// sweepInterval is how often expired entries are removed from the store
+const sweepInterval = time.Minute
+
+// ErrKeyReused is returned when idempotency key is used with different request
+var ErrKeyReused = errors.New("idempotency key was already used with different request")
+
+// Entry is stored result of the request
+type Entry struct {
+	RequestHash string
+	Result      interface{}
+	ExpiresAt   time.Time
+	// pending is set while the request holding the key is processed
+	pending bool
+}
+
+// Store keeps results of completed requests by idempotency key for a limited time
+type Store struct {
+	ttl       time.Duration
+	mu        sync.Mutex
+	entries   map[string]*Entry
+	lastSweep time.Time
+}
+
+// NewStore creates new instance of store, results are kept for ttl
+func NewStore(ttl time.Duration) *Store {
+	return &Store{
+		ttl:       ttl,
+		entries:   make(map[string]*Entry),
+		lastSweep: time.Now(),
+	}
+}
+
+// Get returns not expired entry of completed request stored by key
+func (s *Store) Get(key string) (*Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.get(key, time.Now())
+	if !ok || e.pending {
+		return nil, false
+	}
+	return e, true
+}
+
+// Reserve reserves key for the request when its processing starts. Entry of completed request is returned if the key
+// was already used with the same request, ErrKeyReused is returned if the key is held by different request.
+// Identical concurrent requests aren't rejected, they share computation.
+func (s *Store) Reserve(key, requestHash string) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	e, ok := s.get(key, now)
+	if !ok {
+		s.entries[key] = &Entry{RequestHash: requestHash, ExpiresAt: now.Add(s.ttl), pending: true}
+		return nil, nil
+	}
+	if e.RequestHash != requestHash {
+		return nil, ErrKeyReused
+	}
+	if e.pending {
+		return nil, nil
+	}
+	return e, nil
+}
+
+// Release releases key reserved by the request which failed, so that it can be retried. Keys of completed
+// requests aren't released.
+func (s *Store) Release(key, requestHash string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok && e.pending && e.RequestHash == requestHash {
+		delete(s.entries, key)
+	}
+}
+
+// Put stores result of the request by key, result of the first completed request is kept
+func (s *Store) Put(key, requestHash string, result interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	if e, ok := s.get(key, now); ok && (!e.pending || e.RequestHash != requestHash) {
+		return
+	}
+	s.entries[key] = &Entry{
+		RequestHash: requestHash,
+		Result:      result,
+		ExpiresAt:   now.Add(s.ttl),
+	}
+}
+
+// get returns not expired entry, expired entry is removed
+func (s *Store) get(key string, now time.Time) (*Entry, bool) {
+	e, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if now.After(e.ExpiresAt) {
+		delete(s.entries, key)
+		return nil, false
+	}
+	return e, true
+}
+
+func (s *Store) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	for k, e := range s.entries {
+		if now.After(e.ExpiresAt) {
+			delete(s.entries, k)
+		}
+	}
+	s.lastSweep = now
+}
@@ -0,0 +1,63 @@
+package idempotency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+
+	s := NewStore(50 * time.Millisecond)
+
+	_, ok := s.Get("key")
+	require.False(t, ok)
+
+	s.Put("key", "hash1", "first")
+	s.Put("key", "hash2", "second")
+
+	e, ok := s.Get("key")
+	require.True(t, ok)
+	require.Equal(t, "hash1", e.RequestHash)
+	require.Equal(t, "first", e.Result)
+
+	time.Sleep(60 * time.Millisecond)
+	_, ok = s.Get("key")
+	require.False(t, ok)
+}
+
+func TestStoreReserve(t *testing.T) {
+
+	s := NewStore(time.Minute)
+
+	e, err := s.Reserve("key", "hash1")
+	require.NoError(t, err)
+	require.Nil(t, e)
+
+	// key is held by the request being processed
+	_, err = s.Reserve("key", "hash2")
+	require.ErrorIs(t, err, ErrKeyReused)
+	e, err = s.Reserve("key", "hash1")
+	require.NoError(t, err)
+	require.Nil(t, e)
+	_, ok := s.Get("key")
+	require.False(t, ok)
+
+	// failed request releases the key
+	s.Release("key", "hash2")
+	_, err = s.Reserve("key", "hash2")
+	require.ErrorIs(t, err, ErrKeyReused)
+	s.Release("key", "hash1")
+	_, err = s.Reserve("key", "hash2")
+	require.NoError(t, err)
+
+	s.Put("key", "hash1", "first")
+	s.Put("key", "hash2", "second")
+	s.Release("key", "hash2")
+	e, err = s.Reserve("key", "hash2")
+	require.NoError(t, err)
+	require.Equal(t, "second", e.Result)
+	_, err = s.Reserve("key", "hash1")
+	require.ErrorIs(t, err, ErrKeyReused)
+}
@@ -0,0 +1,117 @@
+package inflight
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Group deduplicates concurrent calls with the same key: the first caller starts the computation and
+// the others wait for it and share its result
+type Group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+type call struct {
+	done    chan struct{}
+	val     interface{}
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// NewGroup creates new instance of Group
+func NewGroup() *Group {
+	return &Group{
+		calls: make(map[string]*call),
+	}
+}
+
+// Do executes fn once for all concurrent callers with the same key and returns its result to each of them.
+// fn is called with a context that is detached from the callers and is cancelled only when every caller
+// has given up waiting. shared reports whether the result was given to more than one caller.
+func (g *Group) Do(ctx context.Context, key string,
+	fn func(ctx context.Context) (interface{}, error)) (v interface{}, shared bool, err error) {
+
+	g.mu.Lock()
+	c, ok := g.calls[key]
+	if ok {
+		c.waiters++
+		g.mu.Unlock()
+		return g.wait(ctx, key, c, true)
+	}
+
+	fnCtx, cancel := context.WithCancel(detached{ctx})
+	c = &call{
+		done:    make(chan struct{}),
+		waiters: 1,
+		cancel:  cancel,
+	}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	go func() {
+		c.val, c.err = fn(fnCtx)
+
+		g.mu.Lock()
+		if g.calls[key] == c {
+			delete(g.calls, key)
+		}
+		g.mu.Unlock()
+
+		cancel()
+		close(c.done)
+	}()
+
+	return g.wait(ctx, key, c, false)
+}
+
+// Len returns number of computations in progress
+func (g *Group) Len() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.calls)
+}
+
+func (g *Group) wait(ctx context.Context, key string, c *call, joined bool) (interface{}, bool, error) {
+	select {
+	case <-c.done:
+		g.mu.Lock()
+		shared := joined || c.waiters > 1
+		g.mu.Unlock()
+		return c.val, shared, c.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		c.waiters--
+		if c.waiters == 0 && g.calls[key] == c {
+			// nobody is interested in the result anymore
+			delete(g.calls, key)
+			c.cancel()
+		}
+		g.mu.Unlock()
+		return nil, false, ctx.Err()
+	}
+}
+
+// detached is a context that keeps values of its parent (e.g. request id for logging),
+// but is never cancelled together with it
+type detached struct {
+	parent context.Context
+}
+
+func (detached) Deadline() (deadline time.Time, ok bool) {
+	return time.Time{}, false
+}
+
+func (detached) Done() <-chan struct{} {
+	return nil
+}
+
+func (detached) Err() error {
+	return nil
+}
+
+func (d detached) Value(key interface{}) interface{} {
+	return d.parent.Value(key)
+}
@@ -0,0 +1,96 @@
+package inflight
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupDoShared(t *testing.T) {
+
+	g := NewGroup()
+	var calls int32
+	release := make(chan struct{})
+
+	fn := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "proof", nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]interface{}, 5)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v, _, err := g.Do(context.Background(), "key", fn)
+			require.NoError(t, err)
+			results[i] = v
+		}(i)
+	}
+
+	// let all callers join the running computation
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	for _, v := range results {
+		require.Equal(t, "proof", v)
+	}
+}
+
+func TestGroupDoCancelledWaiter(t *testing.T) {
+
+	g := NewGroup()
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	go func() {
+		v, _, err := g.Do(context.Background(), "key", func(ctx context.Context) (interface{}, error) {
+			close(started)
+			<-release
+			return "proof", nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, "proof", v)
+	}()
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err := g.Do(ctx, "key", nil)
+	require.ErrorIs(t, err, context.Canceled)
+
+	close(release)
+}
+
+func TestGroupDoCancelledAllWaiters(t *testing.T) {
+
+	g := NewGroup()
+	fnCtxDone := make(chan struct{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	_, _, err := g.Do(ctx, "key", func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		close(fnCtxDone)
+		return nil, ctx.Err()
+	})
+	require.ErrorIs(t, err, context.Canceled)
+
+	select {
+	case <-fnCtxDone:
+	case <-time.After(time.Second):
+		t.Fatal("computation wasn't cancelled after all callers left")
+	}
+}
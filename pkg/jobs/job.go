@@ -0,0 +1,87 @@
+package jobs
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/iden3/go-rapidsnark/types"
+	"github.com/iden3/prover-server/pkg/proof"
+	"github.com/iden3/prover-server/pkg/scheduler"
+	"github.com/pkg/errors"
+)
+
+// Status is a state of proof generation job
+type Status string
+
+const (
+	// StatusQueued means job is waiting for a free worker
+	StatusQueued Status = "queued"
+	// StatusRunning means proof is being generated
+	StatusRunning Status = "running"
+	// StatusDone means proof was generated successfully
+	StatusDone Status = "done"
+	// StatusFailed means proof generation failed
+	StatusFailed Status = "failed"
+)
+
+// Completed returns true if job reached its final state
+func (s Status) Completed() bool {
+	return s == StatusDone || s == StatusFailed
+}
+
+// CallbackStatus is a state of job result delivery to callback URL
+type CallbackStatus string
+
+const (
+	// CallbackPending means result is not delivered yet
+	CallbackPending CallbackStatus = "pending"
+	// CallbackDelivered means callback URL accepted the result
+	CallbackDelivered CallbackStatus = "delivered"
+	// CallbackFailed means all delivery attempts failed and dead letter was recorded
+	CallbackFailed CallbackStatus = "failed"
+)
+
+// Job is a background proof generation task
+type Job struct {
+	ID          string `json:"id"`
+	CircuitName string `json:"circuit_name"`
+	// Priority is a lane the proof waits in, default priority of the circuit is used if it's empty
+	Priority    scheduler.Priority `json:"priority,omitempty"`
+	Inputs      proof.ZKInputs     `json:"inputs,omitempty"`
+	Status      Status             `json:"status"`
+	Phase       proof.Phase        `json:"phase,omitempty"`
+	Result      *types.ZKProof     `json:"result,omitempty"`
+	Error       string             `json:"error,omitempty"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+	CompletedAt *time.Time         `json:"completed_at,omitempty"`
+
+	CallbackURL    string         `json:"callback_url,omitempty"`
+	CallbackStatus CallbackStatus `json:"callback_status,omitempty"`
+}
+
+// WithoutInputs returns copy of the job without inputs, they are not needed by clients waiting for result
+func (j *Job) WithoutInputs() *Job {
+	view := *j
+	view.Inputs = nil
+	return &view
+}
+
+// DeadLetter is a record of job result that couldn't be delivered to callback URL
+type DeadLetter struct {
+	JobID       string    `json:"job_id"`
+	CallbackURL string    `json:"callback_url"`
+	Attempts    int       `json:"attempts"`
+	LastError   string    `json:"last_error"`
+	Payload     []byte    `json:"payload"`
+	FailedAt    time.Time `json:"failed_at"`
+}
+
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "failed to generate job id")
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,436 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/iden3/go-rapidsnark/types"
+	"github.com/iden3/prover-server/pkg/log"
+	"github.com/iden3/prover-server/pkg/proof"
+	"github.com/iden3/prover-server/pkg/scheduler"
+	"github.com/pkg/errors"
+)
+
+// maxRetentionCheckInterval limits how rarely expired jobs are looked for
+const maxRetentionCheckInterval = time.Hour
+
+// subscriberBuffer is a number of job updates kept for slow subscriber
+const subscriberBuffer = 8
+
+// GenerateFunc generates proof for the circuit
+type GenerateFunc func(ctx context.Context, circuitName string, inputs proof.ZKInputs) (*types.ZKProof, error)
+
+// Stats is a snapshot of the job queue state
+type Stats struct {
+	Queued  int `json:"queued"`
+	Running int `json:"running"`
+	Workers int `json:"workers"`
+}
+
+// Options are settings of the manager
+type Options struct {
+	// Workers is a number of jobs run in parallel, 1 is used if it's not set
+	Workers int
+	// Retention is how long completed jobs are kept, they aren't removed if it's 0
+	Retention time.Duration
+	Webhooks  WebhookOptions
+}
+
+// Manager runs proof generation jobs by pool of workers and keeps their state in the store
+type Manager struct {
+	store    Store
+	generate GenerateFunc
+	notifier *Notifier
+	options  Options
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   []string
+	running int
+
+	subsMu sync.Mutex
+	subs   map[string][]chan *Job
+
+	// activeMu guards jobs being generated, their phases are published until generation returns
+	activeMu sync.Mutex
+	active   map[string]*Job
+}
+
+// NewManager creates new instance of Manager
+func NewManager(store Store, generate GenerateFunc, options Options) *Manager {
+	if options.Workers <= 0 {
+		options.Workers = 1
+	}
+	m := &Manager{
+		store:    store,
+		generate: generate,
+		notifier: NewNotifier(options.Webhooks),
+		options:  options,
+		subs:     make(map[string][]chan *Job),
+		active:   make(map[string]*Job),
+	}
+	m.cond = sync.NewCond(&m.mu)
+	return m
+}
+
+// Start resumes jobs that were not completed before restart, starts workers and retention of completed jobs
+func (m *Manager) Start(ctx context.Context) error {
+	var pending, undelivered []*Job
+	err := m.store.ForEach(func(job *Job) error {
+		switch {
+		case !job.Status.Completed():
+			pending = append(pending, job)
+		case job.CallbackStatus == CallbackPending:
+			undelivered = append(undelivered, job)
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to load pending jobs")
+	}
+
+	// keep submission order of resumed jobs
+	sortByCreation(pending)
+	for _, job := range pending {
+		if job.Status == StatusRunning {
+			job.Status = StatusQueued
+			job.UpdatedAt = time.Now()
+			if err = m.store.Save(job); err != nil {
+				return errors.Wrap(err, "failed to requeue job")
+			}
+		}
+		m.enqueue(job.ID)
+	}
+	if len(pending) > 0 {
+		log.Infow("Resumed pending jobs", "count", len(pending))
+	}
+	for _, job := range undelivered {
+		go m.notify(ctx, job)
+	}
+
+	for i := 0; i < m.options.Workers; i++ {
+		go m.work(ctx)
+	}
+	go func() {
+		<-ctx.Done()
+		m.mu.Lock()
+		m.cond.Broadcast()
+		m.mu.Unlock()
+	}()
+
+	if m.options.Retention > 0 {
+		go m.retain(ctx)
+	}
+
+	return nil
+}
+
+// Submit stores new job and puts it into the queue, result is posted to callbackURL if it's not empty.
+// Proof waits for generation in the lane of priority, or of default priority of the circuit if it's empty.
+func (m *Manager) Submit(circuitName string, inputs proof.ZKInputs, callbackURL string,
+	priority scheduler.Priority) (*Job, error) {
+	if callbackURL != "" && m.options.Webhooks.Secret == "" {
+		return nil, ErrCallbacksDisabled
+	}
+	id, err := newJobID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	job := &Job{
+		ID:          id,
+		CircuitName: circuitName,
+		Priority:    priority,
+		Inputs:      inputs,
+		Status:      StatusQueued,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		CallbackURL: callbackURL,
+	}
+	if callbackURL != "" {
+		job.CallbackStatus = CallbackPending
+	}
+	if err = m.store.Save(job); err != nil {
+		return nil, errors.Wrap(err, "failed to save job")
+	}
+
+	m.enqueue(job.ID)
+	return job, nil
+}
+
+// ValidateCallbackURL returns error if result of job can't be posted to the URL: callbacks are disabled or the URL
+// isn't absolute http(s) URL of public host
+func (m *Manager) ValidateCallbackURL(ctx context.Context, callbackURL string) error {
+	return m.notifier.Validate(ctx, callbackURL)
+}
+
+// Get returns job by id or ErrNotFound
+func (m *Manager) Get(id string) (*Job, error) {
+	return m.store.Get(id)
+}
+
+// Stats returns current state of the queue
+func (m *Manager) Stats() Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return Stats{
+		Queued:  len(m.queue),
+		Running: m.running,
+		Workers: m.options.Workers,
+	}
+}
+
+func (m *Manager) enqueue(id string) {
+	m.mu.Lock()
+	m.queue = append(m.queue, id)
+	m.mu.Unlock()
+	m.cond.Signal()
+}
+
+// next blocks until there is a job in the queue, returns false if ctx is done
+func (m *Manager) next(ctx context.Context) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for len(m.queue) == 0 {
+		if ctx.Err() != nil {
+			return "", false
+		}
+		m.cond.Wait()
+	}
+	if ctx.Err() != nil {
+		return "", false
+	}
+	id := m.queue[0]
+	m.queue = m.queue[1:]
+	m.running++
+	return id, true
+}
+
+func (m *Manager) work(ctx context.Context) {
+	for {
+		id, ok := m.next(ctx)
+		if !ok {
+			return
+		}
+		m.run(ctx, id)
+
+		m.mu.Lock()
+		m.running--
+		m.mu.Unlock()
+	}
+}
+
+func (m *Manager) run(ctx context.Context, id string) {
+	job, err := m.store.Get(id)
+	if err != nil {
+		log.Errorw("failed to load job", "job", id, "error", err)
+		return
+	}
+
+	job.Status = StatusRunning
+	job.UpdatedAt = time.Now()
+	if err = m.store.Save(job); err != nil {
+		log.Errorw("failed to update job", "job", id, "error", err)
+		return
+	}
+	m.publish(job)
+
+	log.Debugw("Job started", "job", id, "circuit", job.CircuitName)
+	if job.Priority != "" {
+		ctx = scheduler.WithPriority(ctx, job.Priority)
+	}
+	m.activeMu.Lock()
+	m.active[id] = job
+	m.activeMu.Unlock()
+	progressCtx := proof.WithProgress(ctx, func(phase proof.Phase) { m.progress(id, phase) })
+	result, err := m.generate(progressCtx, job.CircuitName, job.Inputs)
+	// phases reported after generation returned are ignored
+	m.activeMu.Lock()
+	delete(m.active, id)
+	m.activeMu.Unlock()
+	if err != nil && ctx.Err() != nil {
+		// shutting down, job stays running and it's requeued on start
+		log.Debugw("Job interrupted", "job", id)
+		return
+	}
+
+	now := time.Now()
+	job.Phase = ""
+	job.UpdatedAt = now
+	job.CompletedAt = &now
+	if err != nil {
+		log.Errorw("Job failed", "job", id, "error", err)
+		job.Status = StatusFailed
+		job.Error = err.Error()
+	} else {
+		log.Debugw("Job completed", "job", id)
+		job.Status = StatusDone
+		job.Result = result
+	}
+
+	if err = m.store.Save(job); err != nil {
+		log.Errorw("failed to update job", "job", id, "error", err)
+	}
+	m.publish(job)
+
+	if job.CallbackURL != "" {
+		go m.notify(ctx, job)
+	}
+}
+
+// progress saves and publishes phase of the job being generated, it may be called from any goroutine
+func (m *Manager) progress(id string, phase proof.Phase) {
+	m.activeMu.Lock()
+	defer m.activeMu.Unlock()
+
+	job, ok := m.active[id]
+	if !ok || job.Phase == phase {
+		return
+	}
+	job.Phase = phase
+	job.UpdatedAt = time.Now()
+	if err := m.store.Save(job); err != nil {
+		log.Errorw("failed to update job", "job", id, "error", err)
+	}
+	m.publish(job)
+}
+
+// Subscribe returns channel receiving snapshots of the job (without inputs) on every change of its
+// status or phase, the channel is closed after the job is completed. Call returned function to unsubscribe.
+func (m *Manager) Subscribe(id string) (<-chan *Job, func()) {
+	ch := make(chan *Job, subscriberBuffer)
+
+	m.subsMu.Lock()
+	m.subs[id] = append(m.subs[id], ch)
+	m.subsMu.Unlock()
+
+	return ch, func() {
+		m.subsMu.Lock()
+		defer m.subsMu.Unlock()
+		subs := m.subs[id]
+		for i := range subs {
+			if subs[i] == ch {
+				m.subs[id] = append(subs[:i], subs[i+1:]...)
+				close(ch)
+				break
+			}
+		}
+		if len(m.subs[id]) == 0 {
+			delete(m.subs, id)
+		}
+	}
+}
+
+// publish sends snapshot of the job to its subscribers, slow subscribers miss intermediate updates
+func (m *Manager) publish(job *Job) {
+	m.subsMu.Lock()
+	defer m.subsMu.Unlock()
+
+	view := job.WithoutInputs()
+	for _, ch := range m.subs[job.ID] {
+		select {
+		case ch <- view:
+		default:
+		}
+		if job.Status.Completed() {
+			close(ch)
+		}
+	}
+	if job.Status.Completed() {
+		delete(m.subs, job.ID)
+	}
+}
+
+// notify delivers result of completed job to its callback URL, records dead letter if delivery failed
+func (m *Manager) notify(ctx context.Context, job *Job) {
+	payload, err := json.Marshal(job.WithoutInputs())
+	if err != nil {
+		log.Errorw("failed to serialize job result", "job", job.ID, "error", err)
+		return
+	}
+
+	attempts, err := m.notifier.Deliver(ctx, job.ID, job.CallbackURL, payload)
+	if ctx.Err() != nil {
+		// shutting down, delivery will be resumed on start
+		return
+	}
+
+	job.CallbackStatus = CallbackDelivered
+	if err != nil {
+		log.Errorw("Job result delivery failed", "job", job.ID, "attempts", attempts, "error", err)
+		job.CallbackStatus = CallbackFailed
+		dl := &DeadLetter{
+			JobID:       job.ID,
+			CallbackURL: job.CallbackURL,
+			Attempts:    attempts,
+			LastError:   err.Error(),
+			Payload:     payload,
+			FailedAt:    time.Now(),
+		}
+		if err = m.store.SaveDeadLetter(dl); err != nil {
+			log.Errorw("failed to save dead letter", "job", job.ID, "error", err)
+		}
+	}
+
+	job.UpdatedAt = time.Now()
+	if err = m.store.Save(job); err != nil {
+		log.Errorw("failed to update job", "job", job.ID, "error", err)
+	}
+}
+
+// retain periodically deletes jobs completed more than retention period ago
+func (m *Manager) retain(ctx context.Context) {
+	interval := m.options.Retention
+	if interval > maxRetentionCheckInterval {
+		interval = maxRetentionCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := m.DeleteExpired(time.Now().Add(-m.options.Retention))
+			if err != nil {
+				log.Errorw("failed to delete expired jobs", "error", err)
+				continue
+			}
+			if n > 0 {
+				log.Debugw("Expired jobs deleted", "count", n)
+			}
+		}
+	}
+}
+
+// DeleteExpired deletes jobs completed before the given time and returns number of deleted jobs
+func (m *Manager) DeleteExpired(before time.Time) (int, error) {
+	var expired []string
+	err := m.store.ForEach(func(job *Job) error {
+		if job.Status.Completed() && job.CompletedAt != nil && job.CompletedAt.Before(before) {
+			expired = append(expired, job.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, id := range expired {
+		if err = m.store.Delete(id); err != nil {
+			return 0, err
+		}
+	}
+	return len(expired), nil
+}
+
+func sortByCreation(jobs []*Job) {
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
+}
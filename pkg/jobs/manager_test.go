@@ -0,0 +1,160 @@
+package jobs
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iden3/go-rapidsnark/types"
+	"github.com/iden3/prover-server/pkg/proof"
+	"github.com/iden3/prover-server/pkg/scheduler"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func fakeGenerate(ctx context.Context, circuitName string, inputs proof.ZKInputs) (*types.ZKProof, error) {
+	if circuitName == "broken" {
+		return nil, errors.New("failed to calculate witness")
+	}
+	signals := []string{inputs["in"].(string)}
+	if p, ok := scheduler.PriorityFromContext(ctx); ok {
+		signals = append(signals, string(p))
+	}
+	return &types.ZKProof{PubSignals: signals}, nil
+}
+
+func waitCompleted(t *testing.T, m *Manager, id string) *Job {
+	var job *Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = m.Get(id)
+		require.NoError(t, err)
+		return job.Status.Completed()
+	}, time.Second, 10*time.Millisecond)
+	return job
+}
+
+func TestManager(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m := NewManager(NewMemoryStore(), fakeGenerate, Options{Workers: 2})
+	require.NoError(t, m.Start(ctx))
+
+	job, err := m.Submit("auth", proof.ZKInputs{"in": "1"}, "", "")
+	require.NoError(t, err)
+	require.Equal(t, StatusQueued, job.Status)
+
+	job = waitCompleted(t, m, job.ID)
+	require.Equal(t, StatusDone, job.Status)
+	require.Equal(t, []string{"1"}, job.Result.PubSignals)
+	require.NotNil(t, job.CompletedAt)
+
+	// priority of job is passed to proof generation
+	job, err = m.Submit("auth", proof.ZKInputs{"in": "1"}, "", scheduler.PriorityLow)
+	require.NoError(t, err)
+	job = waitCompleted(t, m, job.ID)
+	require.Equal(t, []string{"1", "low"}, job.Result.PubSignals)
+
+	job, err = m.Submit("broken", proof.ZKInputs{"in": "1"}, "", "")
+	require.NoError(t, err)
+	job = waitCompleted(t, m, job.ID)
+	require.Equal(t, StatusFailed, job.Status)
+	require.Contains(t, job.Error, "failed to calculate witness")
+
+	_, err = m.Get("unknown")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	n, err := m.DeleteExpired(time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+}
+
+func TestManagerResumesPendingJobs(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "jobs.db")
+
+	// jobs left by previous run: one queued and one interrupted while running
+	store, err := NewBoltStore(path)
+	require.NoError(t, err)
+	now := time.Now()
+	require.NoError(t, store.Save(&Job{ID: "queued", CircuitName: "auth", Inputs: proof.ZKInputs{"in": "1"},
+		Status: StatusQueued, CreatedAt: now}))
+	require.NoError(t, store.Save(&Job{ID: "running", CircuitName: "auth", Inputs: proof.ZKInputs{"in": "2"},
+		Status: StatusRunning, CreatedAt: now.Add(-time.Second)}))
+	require.NoError(t, store.Close())
+
+	store, err = NewBoltStore(path)
+	require.NoError(t, err)
+	defer store.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m := NewManager(store, fakeGenerate, Options{Workers: 1})
+	require.NoError(t, m.Start(ctx))
+
+	job := waitCompleted(t, m, "queued")
+	require.Equal(t, StatusDone, job.Status)
+	job = waitCompleted(t, m, "running")
+	require.Equal(t, StatusDone, job.Status)
+	require.Equal(t, []string{"2"}, job.Result.PubSignals)
+}
+
+func TestManagerShutdown(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started := make(chan struct{})
+	generate := func(ctx context.Context, circuitName string, inputs proof.ZKInputs) (*types.ZKProof, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	store := NewMemoryStore()
+	m := NewManager(store, generate, Options{Workers: 1})
+	require.NoError(t, m.Start(ctx))
+	job, err := m.Submit("auth", proof.ZKInputs{"in": "1"}, "", "")
+	require.NoError(t, err)
+	<-started
+	cancel()
+
+	// interrupted job isn't failed, so that it's resumed on start
+	require.Eventually(t, func() bool { return m.Stats().Running == 0 }, time.Second, 10*time.Millisecond)
+	job, err = store.Get(job.ID)
+	require.NoError(t, err)
+	require.Equal(t, StatusRunning, job.Status)
+}
+
+func TestManagerSubscribe(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	release := make(chan struct{})
+	generate := func(ctx context.Context, circuitName string, inputs proof.ZKInputs) (*types.ZKProof, error) {
+		<-release
+		return fakeGenerate(ctx, circuitName, inputs)
+	}
+
+	m := NewManager(NewMemoryStore(), generate, Options{Workers: 1})
+	require.NoError(t, m.Start(ctx))
+
+	job, err := m.Submit("auth", proof.ZKInputs{"in": "1"}, "", "")
+	require.NoError(t, err)
+
+	updates, unsubscribe := m.Subscribe(job.ID)
+	defer unsubscribe()
+	close(release)
+
+	var statuses []Status
+	for update := range updates {
+		require.Nil(t, update.Inputs)
+		statuses = append(statuses, update.Status)
+	}
+	require.Equal(t, StatusDone, statuses[len(statuses)-1])
+}
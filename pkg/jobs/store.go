@@ -0,0 +1,235 @@
+package jobs
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// ErrNotFound is returned when job doesn't exist in the store
+var ErrNotFound = errors.New("job not found")
+
+// Store keeps state, inputs and results of jobs
+type Store interface {
+	// Save creates or updates the job
+	Save(job *Job) error
+	// Get returns job by id or ErrNotFound
+	Get(id string) (*Job, error)
+	// ForEach calls fn for every stored job
+	ForEach(fn func(job *Job) error) error
+	// Delete removes job by id
+	Delete(id string) error
+	// SaveDeadLetter records job result that couldn't be delivered
+	SaveDeadLetter(dl *DeadLetter) error
+	// ForEachDeadLetter calls fn for every dead letter
+	ForEachDeadLetter(fn func(dl *DeadLetter) error) error
+	// Close releases resources of the store
+	Close() error
+}
+
+var (
+	jobsBucket        = []byte("jobs")
+	deadLettersBucket = []byte("deadLetters")
+)
+
+// BoltStore is Store persisted to BoltDB file, so jobs survive restarts
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore opens (or creates) BoltDB file by path
+func NewBoltStore(path string) (*BoltStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, errors.Wrap(err, "failed to create job store directory")
+	}
+
+	db, err := bolt.Open(path, 0o600, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open job store")
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{jobsBucket, deadLettersBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, errors.Wrap(err, "failed to init job store")
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+// Save creates or updates the job
+func (s *BoltStore) Save(job *Job) error {
+	b, err := json.Marshal(job)
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize job")
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(jobsBucket).Put([]byte(job.ID), b)
+	})
+}
+
+// Get returns job by id or ErrNotFound
+func (s *BoltStore) Get(id string) (*Job, error) {
+	var job *Job
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(jobsBucket).Get([]byte(id))
+		if b == nil {
+			return ErrNotFound
+		}
+		job = &Job{}
+		return json.Unmarshal(b, job)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// ForEach calls fn for every stored job
+func (s *BoltStore) ForEach(fn func(job *Job) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(jobsBucket).ForEach(func(_, v []byte) error {
+			job := &Job{}
+			if err := json.Unmarshal(v, job); err != nil {
+				return errors.Wrap(err, "failed to parse stored job")
+			}
+			return fn(job)
+		})
+	})
+}
+
+// Delete removes job by id
+func (s *BoltStore) Delete(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(jobsBucket).Delete([]byte(id))
+	})
+}
+
+// SaveDeadLetter records job result that couldn't be delivered
+func (s *BoltStore) SaveDeadLetter(dl *DeadLetter) error {
+	b, err := json.Marshal(dl)
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize dead letter")
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(deadLettersBucket).Put([]byte(dl.JobID), b)
+	})
+}
+
+// ForEachDeadLetter calls fn for every dead letter
+func (s *BoltStore) ForEachDeadLetter(fn func(dl *DeadLetter) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(deadLettersBucket).ForEach(func(_, v []byte) error {
+			dl := &DeadLetter{}
+			if err := json.Unmarshal(v, dl); err != nil {
+				return errors.Wrap(err, "failed to parse dead letter")
+			}
+			return fn(dl)
+		})
+	})
+}
+
+// Close closes BoltDB file
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+// MemoryStore is Store that keeps jobs in memory only
+type MemoryStore struct {
+	mu          sync.RWMutex
+	jobs        map[string]Job
+	deadLetters map[string]DeadLetter
+}
+
+// NewMemoryStore creates new instance of MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		jobs:        make(map[string]Job),
+		deadLetters: make(map[string]DeadLetter),
+	}
+}
+
+// Save creates or updates the job
+func (s *MemoryStore) Save(job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = *job
+	return nil
+}
+
+// Get returns job by id or ErrNotFound
+func (s *MemoryStore) Get(id string) (*Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &job, nil
+}
+
+// ForEach calls fn for every stored job
+func (s *MemoryStore) ForEach(fn func(job *Job) error) error {
+	s.mu.RLock()
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+	s.mu.RUnlock()
+
+	for i := range jobs {
+		if err := fn(&jobs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes job by id
+func (s *MemoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.jobs, id)
+	return nil
+}
+
+// SaveDeadLetter records job result that couldn't be delivered
+func (s *MemoryStore) SaveDeadLetter(dl *DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deadLetters[dl.JobID] = *dl
+	return nil
+}
+
+// ForEachDeadLetter calls fn for every dead letter
+func (s *MemoryStore) ForEachDeadLetter(fn func(dl *DeadLetter) error) error {
+	s.mu.RLock()
+	dls := make([]DeadLetter, 0, len(s.deadLetters))
+	for _, dl := range s.deadLetters {
+		dls = append(dls, dl)
+	}
+	s.mu.RUnlock()
+
+	for i := range dls {
+		if err := fn(&dls[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close does nothing for MemoryStore
+func (s *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,198 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SignatureHeader is a header with HMAC-SHA256 signature of callback timestamp and body
+const SignatureHeader = "X-Prover-Signature"
+
+// TimestampHeader is a header with Unix time of callback delivery attempt, receivers reject stale ones to prevent replays
+const TimestampHeader = "X-Prover-Timestamp"
+
+// JobIDHeader is a header with id of the job callback is sent for
+const JobIDHeader = "X-Prover-Job-Id"
+
+const (
+	defaultWebhookAttempts       = 5
+	defaultWebhookInitialBackoff = time.Second
+	defaultWebhookMaxBackoff     = 5 * time.Minute
+	defaultWebhookTimeout        = 10 * time.Second
+)
+
+var (
+	// ErrCallbacksDisabled is returned for callback URL when webhook secret isn't configured
+	ErrCallbacksDisabled = errors.New("callbacks are disabled, webhook secret isn't configured")
+	// ErrIllegalCallbackURL is returned for callback URL which isn't absolute http(s) URL of public host
+	ErrIllegalCallbackURL = errors.New("illegal callback URL")
+)
+
+// WebhookOptions are settings of job result delivery to callback URLs, defaults are used for zero values
+type WebhookOptions struct {
+	// Secret is a key for HMAC-SHA256 signature of callback body
+	Secret         string
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Timeout        time.Duration
+	// AllowPrivateNetworks allows callbacks to loopback, private and link-local addresses
+	AllowPrivateNetworks bool
+}
+
+// Notifier delivers job results to callback URLs
+type Notifier struct {
+	client *http.Client
+	config WebhookOptions
+}
+
+// NewNotifier creates new instance of Notifier
+func NewNotifier(config WebhookOptions) *Notifier {
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaultWebhookAttempts
+	}
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = defaultWebhookInitialBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = defaultWebhookMaxBackoff
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultWebhookTimeout
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !config.AllowPrivateNetworks {
+		// addresses are checked when connection is made, so that host can't be resolved to private address after
+		// callback URL was validated, requests via proxy are not allowed
+		transport.Proxy = nil
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: dialPublic}
+		transport.DialContext = dialer.DialContext
+	}
+	return &Notifier{
+		client: &http.Client{Timeout: config.Timeout, Transport: transport},
+		config: config,
+	}
+}
+
+// Validate checks that callbacks are enabled and callback URL is absolute http(s) URL, its host must not resolve
+// to private address unless private networks are allowed
+func (n *Notifier) Validate(ctx context.Context, callbackURL string) error {
+	if n.config.Secret == "" {
+		return ErrCallbacksDisabled
+	}
+	u, err := url.Parse(callbackURL)
+	if err != nil {
+		return errors.Wrap(ErrIllegalCallbackURL, err.Error())
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.Wrap(ErrIllegalCallbackURL, "callback_url must be absolute http(s) URL")
+	}
+	if n.config.AllowPrivateNetworks {
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return errors.Wrapf(ErrIllegalCallbackURL, "can't resolve host: %v", err)
+	}
+	for _, addr := range addrs {
+		if !publicIP(addr.IP) {
+			return errors.Wrapf(ErrIllegalCallbackURL, "host resolves to private address %s", addr.IP)
+		}
+	}
+	return nil
+}
+
+// Sign returns value of signature header for the body sent at timestamp (Unix time), signed message
+// is "<timestamp>.<body>"
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Deliver posts payload to url retrying with exponential backoff, returns number of attempts made
+// and the last error if none of them succeeded
+func (n *Notifier) Deliver(ctx context.Context, jobID, url string, payload []byte) (int, error) {
+	backoff := n.config.InitialBackoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = n.post(ctx, jobID, url, payload)
+		if err == nil || attempt >= n.config.MaxAttempts {
+			return attempt, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return attempt, ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > n.config.MaxBackoff {
+			backoff = n.config.MaxBackoff
+		}
+	}
+}
+
+func (n *Notifier) post(ctx context.Context, jobID, url string, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return errors.Wrap(err, "failed to create callback request")
+	}
+	if n.config.Secret == "" {
+		return ErrCallbacksDisabled
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(JobIDHeader, jobID)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(n.config.Secret, timestamp, payload))
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to send callback")
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("callback responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// dialPublic refuses connections to private addresses
+func dialPublic(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+		return errors.Wrapf(ErrIllegalCallbackURL, "connection to private address %s", host)
+	}
+	return nil
+}
+
+// sharedAddressSpace is carrier-grade NAT range (RFC 6598), it's used for metadata services by some clouds
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// publicIP returns false for loopback, private, shared, link-local (including cloud metadata), multicast and
+// unspecified addresses
+func publicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !sharedAddressSpace.Contains(ip) && !ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() && !ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
@@ -0,0 +1,156 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/iden3/prover-server/pkg/proof"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifierRetriesAndSigns(t *testing.T) {
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		timestamp, err := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+		require.NoError(t, err)
+		require.InDelta(t, time.Now().Unix(), timestamp, 5)
+		require.Equal(t, Sign("secret", timestamp, body), r.Header.Get(SignatureHeader))
+		require.NotEqual(t, Sign("secret", timestamp-1, body), r.Header.Get(SignatureHeader))
+		require.Equal(t, "job1", r.Header.Get(JobIDHeader))
+
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	n := NewNotifier(WebhookOptions{Secret: "secret", MaxAttempts: 5, InitialBackoff: time.Millisecond,
+		AllowPrivateNetworks: true})
+	attempts, err := n.Deliver(context.Background(), "job1", srv.URL, []byte(`{"id":"job1"}`))
+	require.NoError(t, err)
+	require.Equal(t, 3, attempts)
+}
+
+func TestNotifierRejectsPrivateAddresses(t *testing.T) {
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("callback to private address was sent")
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	require.ErrorIs(t, NewNotifier(WebhookOptions{}).Validate(ctx, "https://example.com"), ErrCallbacksDisabled)
+
+	n := NewNotifier(WebhookOptions{Secret: "secret", MaxAttempts: 1})
+	for _, callbackURL := range []string{
+		srv.URL,
+		"http://localhost/callback",
+		"http://169.254.169.254/latest/meta-data",
+		"http://10.0.0.1/callback",
+		"http://[::1]/callback",
+		"http://100.100.100.200/callback",
+		"ftp://example.com/callback",
+		"/callback",
+	} {
+		require.ErrorIs(t, n.Validate(ctx, callbackURL), ErrIllegalCallbackURL, callbackURL)
+	}
+	require.NoError(t, n.Validate(ctx, "https://93.184.215.14/callback"))
+
+	// address is checked again when connection is made
+	_, err := n.Deliver(ctx, "job1", srv.URL, []byte(`{"id":"job1"}`))
+	require.ErrorIs(t, err, ErrIllegalCallbackURL)
+
+	n = NewNotifier(WebhookOptions{Secret: "secret", AllowPrivateNetworks: true})
+	require.NoError(t, n.Validate(ctx, srv.URL))
+}
+
+func TestManagerCallback(t *testing.T) {
+
+	delivered := make(chan *Job, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		job := &Job{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(job))
+		delivered <- job
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m := NewManager(NewMemoryStore(), fakeGenerate, Options{
+		Workers:  1,
+		Webhooks: WebhookOptions{Secret: "secret", AllowPrivateNetworks: true},
+	})
+	require.NoError(t, m.Start(ctx))
+
+	// callbacks require secret
+	_, err := NewManager(NewMemoryStore(), fakeGenerate, Options{}).Submit("auth", nil, srv.URL, "")
+	require.ErrorIs(t, err, ErrCallbacksDisabled)
+
+	job, err := m.Submit("auth", proof.ZKInputs{"in": "1"}, srv.URL, "")
+	require.NoError(t, err)
+	require.Equal(t, CallbackPending, job.CallbackStatus)
+
+	select {
+	case res := <-delivered:
+		require.Equal(t, job.ID, res.ID)
+		require.Equal(t, StatusDone, res.Status)
+		require.Equal(t, []string{"1"}, res.Result.PubSignals)
+		require.Nil(t, res.Inputs)
+	case <-time.After(time.Second):
+		t.Fatal("callback wasn't delivered")
+	}
+
+	require.Eventually(t, func() bool {
+		job, err = m.Get(job.ID)
+		require.NoError(t, err)
+		return job.CallbackStatus == CallbackDelivered
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestManagerCallbackDeadLetter(t *testing.T) {
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := NewMemoryStore()
+	webhooks := WebhookOptions{Secret: "secret", MaxAttempts: 2, InitialBackoff: time.Millisecond, AllowPrivateNetworks: true}
+	m := NewManager(store, fakeGenerate, Options{Workers: 1, Webhooks: webhooks})
+	require.NoError(t, m.Start(ctx))
+
+	job, err := m.Submit("broken", proof.ZKInputs{"in": "1"}, srv.URL, "")
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		job, err = m.Get(job.ID)
+		require.NoError(t, err)
+		return job.CallbackStatus == CallbackFailed
+	}, time.Second, 10*time.Millisecond)
+
+	var dls []*DeadLetter
+	require.NoError(t, store.ForEachDeadLetter(func(dl *DeadLetter) error {
+		dls = append(dls, dl)
+		return nil
+	}))
+	require.Len(t, dls, 1)
+	require.Equal(t, job.ID, dls[0].JobID)
+	require.Equal(t, 2, dls[0].Attempts)
+	require.Contains(t, dls[0].LastError, "500")
+}
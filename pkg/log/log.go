@@ -47,15 +47,20 @@ func SetLevel(level Level) {
 	logLevel.SetLevel(zapcore.Level(level))
 }
 
-// SetLevelStr sets level of default logger from level name
+// SetLevelStr sets level of default logger from level name, level isn't changed if the name is invalid
 // Valid values: debug, info, warn, error, dpanic, panic, fatal
-func SetLevelStr(levelStr string) {
-	l := getDefaultLoggerOrPanic() // init logger if it hasn't yet been
-	err := logLevel.UnmarshalText([]byte(levelStr))
-	if err != nil {
-		l.Error("can't change log level: invalid string value provided")
-		return
+func SetLevelStr(levelStr string) error {
+	getDefaultLoggerOrPanic() // init logger if it hasn't yet been
+	if err := logLevel.UnmarshalText([]byte(levelStr)); err != nil {
+		return errors.Wrap(err, "invalid log level")
 	}
+	return nil
+}
+
+// GetLevelStr returns name of current level of default logger
+func GetLevelStr() string {
+	getDefaultLoggerOrPanic() // init logger if it hasn't yet been
+	return logLevel.Level().String()
 }
 
 func getDefaultLoggerOrPanic() *zap.SugaredLogger {
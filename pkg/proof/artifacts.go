@@ -0,0 +1,126 @@
+package proof
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// Names of circuit artifact files in circuit directory
+const (
+	WasmFile            = "circuit.wasm"
+	ZKeyFile            = "circuit_final.zkey"
+	VerificationKeyFile = "verification_key.json"
+)
+
+// DefaultArtifactFiles is the default layout of circuit directory
+var DefaultArtifactFiles = ArtifactFiles{
+	Wasm:            WasmFile,
+	ZKey:            ZKeyFile,
+	VerificationKey: VerificationKeyFile,
+}
+
+var (
+	wasmMagic = []byte("\x00asm")
+	zkeyMagic = []byte("zkey")
+)
+
+// Artifacts are contents of circuit files required for proof generation and verification
+type Artifacts struct {
+	Wasm            []byte
+	ZKey            []byte
+	VerificationKey []byte
+	// ZKeyPath is a path of zkey file on disk, if it's set ZKey isn't read for provers implementing FileProver
+	ZKeyPath string
+}
+
+// ArtifactFiles are slash separated paths of artifact files relative to circuit directory
+type ArtifactFiles struct {
+	Wasm            string `json:"wasm"`
+	ZKey            string `json:"zkey"`
+	VerificationKey string `json:"verification_key"`
+}
+
+// List returns paths of all artifact files
+func (f ArtifactFiles) List() []string {
+	return []string{f.Wasm, f.ZKey, f.VerificationKey}
+}
+
+// LoadArtifacts reads artifacts from circuit directory with default layout
+func LoadArtifacts(circuitPath string) (*Artifacts, error) {
+	return ReadArtifacts(os.DirFS(circuitPath), DefaultArtifactFiles)
+}
+
+// ReadArtifacts reads artifact files from file system of circuit, zkey isn't read if its path is empty
+func ReadArtifacts(fsys fs.FS, files ArtifactFiles) (*Artifacts, error) {
+	var a Artifacts
+	var err error
+	if a.Wasm, err = fs.ReadFile(fsys, files.Wasm); err != nil {
+		return nil, errors.Wrap(err, "failed to read wasm file")
+	}
+	if files.ZKey != "" {
+		if a.ZKey, err = fs.ReadFile(fsys, files.ZKey); err != nil {
+			return nil, errors.Wrap(err, "failed to read zkey file")
+		}
+	}
+	if a.VerificationKey, err = fs.ReadFile(fsys, files.VerificationKey); err != nil {
+		return nil, errors.Wrap(err, "failed to read verification_key file")
+	}
+	return &a, nil
+}
+
+// ValidateFiles checks formats of artifact files without reading wasm and zkey files entirely, it doesn't
+// check that they belong to the same circuit. It returns content of verification key file.
+func ValidateFiles(fsys fs.FS, files ArtifactFiles) ([]byte, error) {
+	for _, f := range []struct {
+		name  string
+		magic []byte
+		err   string
+	}{
+		{files.Wasm, wasmMagic, "wasm file is not a WebAssembly module"},
+		{files.ZKey, zkeyMagic, "zkey file is not a zkey"},
+	} {
+		header, err := readHeader(fsys, f.name, len(f.magic))
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(header, f.magic) {
+			return nil, errors.New(f.err)
+		}
+	}
+
+	vkey, err := fs.ReadFile(fsys, files.VerificationKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read verification_key file")
+	}
+	var key struct {
+		Protocol string `json:"protocol"`
+	}
+	if err = json.Unmarshal(vkey, &key); err != nil {
+		return nil, errors.Wrap(err, "failed to parse verification_key file")
+	}
+	if key.Protocol != "groth16" {
+		return nil, errors.Errorf("unsupported protocol %q of verification key", key.Protocol)
+	}
+	return vkey, nil
+}
+
+// readHeader reads up to n first bytes of the file
+func readHeader(fsys fs.FS, name string, n int) ([]byte, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", name)
+	}
+	defer f.Close()
+
+	header := make([]byte, n)
+	n, err = io.ReadFull(f, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, errors.Wrapf(err, "failed to read %s", name)
+	}
+	return header[:n], nil
+}
@@ -0,0 +1,155 @@
+package proof
+
+import (
+	"context"
+	"math/big"
+	"runtime"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/fft"
+	"github.com/iden3/go-rapidsnark/types"
+	"github.com/pkg/errors"
+)
+
+// GnarkProver generates proofs in pure Go with bn254 arithmetic of gnark-crypto, proving keys of snarkjs zkey
+// files are used as is. It's slower than rapidsnark, but requires neither native library nor executable.
+// Proving key is parsed for every proof, so that its copy isn't kept in memory in addition to zkey.
+type GnarkProver struct{}
+
+// Prove generates proof
+func (p *GnarkProver) Prove(ctx context.Context, zkey, wtns []byte) (*types.ZKProof, error) {
+	pk, err := parseZKey(zkey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read zkey")
+	}
+	w, err := parseWitness(wtns)
+	if err != nil {
+		return nil, err
+	}
+	if len(w) != pk.nVars {
+		return nil, errors.Errorf("witness has %d signals, circuit has %d", len(w), pk.nVars)
+	}
+
+	h, err := pk.quotient(ctx, w)
+	if err != nil {
+		return nil, err
+	}
+
+	config := ecc.MultiExpConfig{NbTasks: runtime.NumCPU()}
+	var piA, piB1, piC, piH bn254.G1Jac
+	var piB bn254.G2Jac
+	if _, err = piA.MultiExp(pk.a, w, config); err != nil {
+		return nil, errors.Wrap(err, "failed to compute A")
+	}
+	if _, err = piB1.MultiExp(pk.b1, w, config); err != nil {
+		return nil, errors.Wrap(err, "failed to compute B")
+	}
+	if _, err = piB.MultiExp(pk.b2, w, config); err != nil {
+		return nil, errors.Wrap(err, "failed to compute B")
+	}
+	if _, err = piC.MultiExp(pk.c, w[pk.nPublic+1:], config); err != nil {
+		return nil, errors.Wrap(err, "failed to compute C")
+	}
+	if _, err = piH.MultiExp(pk.h, h, config); err != nil {
+		return nil, errors.Wrap(err, "failed to compute H")
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var r, s fr.Element
+	if _, err = r.SetRandom(); err != nil {
+		return nil, errors.Wrap(err, "failed to generate randomness")
+	}
+	if _, err = s.SetRandom(); err != nil {
+		return nil, errors.Wrap(err, "failed to generate randomness")
+	}
+	var rBig, sBig, rsBig big.Int
+	r.BigInt(&rBig)
+	s.BigInt(&sBig)
+	var rs fr.Element
+	rs.Mul(&r, &s).Neg(&rs).BigInt(&rsBig)
+
+	var delta1 bn254.G1Jac
+	delta1.FromAffine(&pk.delta1)
+	var t bn254.G1Jac
+
+	// A = alpha + sum(a_i * w_i) + r * delta
+	piA.AddMixed(&pk.alpha1)
+	piA.AddAssign(t.ScalarMultiplication(&delta1, &rBig))
+
+	// B = beta + sum(b_i * w_i) + s * delta
+	var delta2 bn254.G2Jac
+	delta2.FromAffine(&pk.delta2)
+	piB.AddMixed(&pk.beta2)
+	piB.AddAssign(delta2.ScalarMultiplication(&delta2, &sBig))
+	piB1.AddMixed(&pk.beta1)
+	piB1.AddAssign(t.ScalarMultiplication(&delta1, &sBig))
+
+	// C = sum(c_i * w_i) + H + s * A + r * B1 - r * s * delta
+	piC.AddAssign(&piH)
+	piC.AddAssign(t.ScalarMultiplication(&piA, &sBig))
+	piC.AddAssign(t.ScalarMultiplication(&piB1, &rBig))
+	piC.AddAssign(t.ScalarMultiplication(&delta1, &rsBig))
+
+	var a, c bn254.G1Affine
+	var b bn254.G2Affine
+	a.FromJacobian(&piA)
+	b.FromJacobian(&piB)
+	c.FromJacobian(&piC)
+
+	zkp := &types.ZKProof{
+		Proof: &types.ProofData{
+			A: []string{a.X.String(), a.Y.String(), "1"},
+			B: [][]string{
+				{b.X.A0.String(), b.X.A1.String()},
+				{b.Y.A0.String(), b.Y.A1.String()},
+				{"1", "0"},
+			},
+			C:        []string{c.X.String(), c.Y.String(), "1"},
+			Protocol: "groth16",
+		},
+		PubSignals: make([]string, pk.nPublic),
+	}
+	for i := range zkp.PubSignals {
+		zkp.PubSignals[i] = w[i+1].String()
+	}
+	return zkp, nil
+}
+
+// quotient returns evaluations of A*B-C on odd powers of root of unity of order 2*domainSize,
+// which are scalars of H points of zkey
+func (pk *provingKey) quotient(ctx context.Context, w []fr.Element) ([]fr.Element, error) {
+	n := pk.domain.Cardinality
+	a := make([]fr.Element, n)
+	b := make([]fr.Element, n)
+	var t fr.Element
+	for i := range pk.coefs {
+		coef := &pk.coefs[i]
+		t.Mul(&coef.value, &w[coef.signal])
+		if coef.matrix == 0 {
+			a[coef.constraint].Add(&a[coef.constraint], &t)
+		} else {
+			b[coef.constraint].Add(&b[coef.constraint], &t)
+		}
+	}
+	// C is evaluated as A*B since constraints are satisfied by witness
+	c := make([]fr.Element, n)
+	for i := range c {
+		c[i].Mul(&a[i], &b[i])
+	}
+
+	for _, evaluations := range [][]fr.Element{a, b, c} {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		pk.domain.FFTInverse(evaluations, fft.DIF)
+		pk.domain.FFT(evaluations, fft.DIT, fft.OnCoset())
+	}
+	for i := range a {
+		a[i].Mul(&a[i], &b[i]).Sub(&a[i], &c[i])
+	}
+	return a, nil
+}
@@ -0,0 +1,288 @@
+package proof
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/fft"
+	"github.com/iden3/go-rapidsnark/verifier"
+	"github.com/iden3/prover-server/pkg/app/configs"
+	"github.com/stretchr/testify/require"
+)
+
+// testCircuit has signals [1, out, x, y, sum] with public out and constraints x*y = out, (x+y)*1 = sum
+var testCircuit = struct {
+	nVars, nPublic int
+	// constraints are A, B and C rows as signal coefficients
+	constraints [][3]map[int]int64
+}{
+	nVars:   5,
+	nPublic: 1,
+	constraints: [][3]map[int]int64{
+		{{2: 1}, {3: 1}, {1: 1}},
+		{{2: 1, 3: 1}, {0: 1}, {4: 1}},
+	},
+}
+
+func TestGnarkProver(t *testing.T) {
+	zkey, vkey := testSetup(t)
+	p, err := NewProver(configs.ProverBackendConfig{Type: BackendGnark})
+	require.NoError(t, err)
+
+	zkp, err := p.Prove(context.Background(), zkey, testWitness(15, 3, 5, 8))
+	require.NoError(t, err)
+	require.Equal(t, []string{"15"}, zkp.PubSignals)
+	require.NoError(t, verifier.VerifyGroth16(*zkp, vkey))
+
+	// proof of witness which doesn't satisfy constraints isn't valid
+	zkp, err = p.Prove(context.Background(), zkey, testWitness(16, 3, 5, 8))
+	require.NoError(t, err)
+	require.Error(t, verifier.VerifyGroth16(*zkp, vkey))
+
+	_, err = p.Prove(context.Background(), zkey, testWitness(15, 3, 5))
+	require.Error(t, err)
+	_, err = p.Prove(context.Background(), []byte("zkey"), testWitness(15, 3, 5, 8))
+	require.Error(t, err)
+}
+
+func TestGnarkProverZKeyFixture(t *testing.T) {
+	// testdata/multiplier is a zkey with 10 sections of snarkjs with public out, sum and private x, y,
+	// constraints x*y = out, (x+y)*1 = sum
+	zkey, err := os.ReadFile("testdata/multiplier/circuit_final.zkey")
+	require.NoError(t, err)
+	vkey, err := os.ReadFile("testdata/multiplier/verification_key.json")
+	require.NoError(t, err)
+
+	// coefficients include rows of public signals after the constraints, the prover doesn't add them
+	pk, err := parseZKey(zkey)
+	require.NoError(t, err)
+	var one fr.Element
+	one.SetOne()
+	for s := uint32(0); s <= uint32(pk.nPublic); s++ {
+		require.Contains(t, pk.coefs, zkeyCoef{matrix: 0, constraint: 2 + s, signal: s, value: one})
+	}
+
+	// circuit.wasm calculates witness of the circuit with wazero
+	wasm, err := os.ReadFile("testdata/multiplier/circuit.wasm")
+	require.NoError(t, err)
+	wtns, err := NewWazeroCalculator().CalculateWitness(context.Background(), wasm, ZKInputs{"x": 3, "y": 5})
+	require.NoError(t, err)
+	require.Equal(t, testWitness(15, 8, 3, 5), wtns)
+
+	p, err := NewProver(configs.ProverBackendConfig{Type: BackendGnark})
+	require.NoError(t, err)
+	zkp, err := p.Prove(context.Background(), zkey, wtns)
+	require.NoError(t, err)
+	require.Equal(t, []string{"15", "8"}, zkp.PubSignals)
+	require.NoError(t, verifier.VerifyGroth16(*zkp, vkey))
+
+	zkp, err = p.Prove(context.Background(), zkey, testWitness(15, 9, 3, 5))
+	require.NoError(t, err)
+	require.Error(t, verifier.VerifyGroth16(*zkp, vkey))
+}
+
+// testSetup generates zkey and verification key of testCircuit in snarkjs format with random toxic waste
+func testSetup(t *testing.T) (zkey, vkey []byte) {
+	t.Helper()
+
+	nConstraints := len(testCircuit.constraints)
+	n := uint64(4) // nConstraints + nPublic + 1 rounded to power of 2
+	var tau, alpha, beta, gamma, delta fr.Element
+	for _, e := range []*fr.Element{&tau, &alpha, &beta, &gamma, &delta} {
+		_, err := e.SetRandom()
+		require.NoError(t, err)
+	}
+
+	// matrices A, B, C with constraints of public signals added like snarkjs does
+	coefs := make([][3]map[int]int64, n)
+	copy(coefs, testCircuit.constraints)
+	for s := 0; s <= testCircuit.nPublic; s++ {
+		coefs[nConstraints+s] = [3]map[int]int64{{s: 1}}
+	}
+
+	// u, v, w are evaluations of signal polynomials of A, B, C at tau
+	u := make([]fr.Element, testCircuit.nVars)
+	v := make([]fr.Element, testCircuit.nVars)
+	w := make([]fr.Element, testCircuit.nVars)
+	for c, row := range coefs {
+		l := lagrange(n, uint64(c), &tau)
+		for m, evals := range [][]fr.Element{u, v, w} {
+			for s, coef := range row[m] {
+				var t fr.Element
+				t.SetInt64(coef).Mul(&t, &l)
+				evals[s].Add(&evals[s], &t)
+			}
+		}
+	}
+
+	_, _, g1, g2 := bn254.Generators()
+	mul1 := func(e fr.Element) bn254.G1Affine {
+		var p bn254.G1Affine
+		return *p.ScalarMultiplication(&g1, e.BigInt(new(big.Int)))
+	}
+	mul2 := func(e fr.Element) bn254.G2Affine {
+		var p bn254.G2Affine
+		return *p.ScalarMultiplication(&g2, e.BigInt(new(big.Int)))
+	}
+	// (beta*u + alpha*v + w) / divisor
+	lc := func(s int, divisor *fr.Element) fr.Element {
+		var e, t fr.Element
+		e.Mul(&beta, &u[s])
+		t.Mul(&alpha, &v[s])
+		e.Add(&e, &t).Add(&e, &w[s])
+		t.Inverse(divisor)
+		return *e.Mul(&e, &t)
+	}
+
+	var zk bytes.Buffer
+	section := func(typ uint32, data []byte) {
+		writeLE(&zk, typ, uint64(len(data)))
+		zk.Write(data)
+	}
+	zk.WriteString("zkey")
+	writeLE(&zk, uint32(1), uint32(9))
+	section(zkeySectionHeader, le32(zkeyProtocolGroth16))
+
+	var h bytes.Buffer
+	writeLE(&h, uint32(fieldSize))
+	h.Write(leBytes(fp.Modulus()))
+	writeLE(&h, uint32(fieldSize))
+	h.Write(leBytes(fr.Modulus()))
+	writeLE(&h, uint32(testCircuit.nVars), uint32(testCircuit.nPublic), uint32(n))
+	writeG1(&h, mul1(alpha))
+	writeG1(&h, mul1(beta))
+	writeG2(&h, mul2(beta))
+	writeG2(&h, mul2(gamma))
+	writeG1(&h, mul1(delta))
+	writeG2(&h, mul2(delta))
+	section(zkeySectionGroth16Header, h.Bytes())
+
+	var ic, coefsSection, a, b1, b2, c, hs bytes.Buffer
+	for s := 0; s <= testCircuit.nPublic; s++ {
+		writeG1(&ic, mul1(lc(s, &gamma)))
+	}
+	section(3, ic.Bytes())
+
+	var nCoefs uint32
+	for constraint, row := range coefs {
+		for m := uint32(0); m < 2; m++ {
+			for s, coef := range row[m] {
+				// coefficient is stored multiplied by montR^2
+				var e fr.Element
+				e.SetInt64(coef).Mul(&e, &montR).Mul(&e, &montR)
+				writeLE(&coefsSection, m, uint32(constraint), uint32(s))
+				coefsSection.Write(leBytes(e.BigInt(new(big.Int))))
+				nCoefs++
+			}
+		}
+	}
+	section(zkeySectionCoefs, append(le32(nCoefs), coefsSection.Bytes()...))
+
+	for s := 0; s < testCircuit.nVars; s++ {
+		writeG1(&a, mul1(u[s]))
+		writeG1(&b1, mul1(v[s]))
+		writeG2(&b2, mul2(v[s]))
+		if s > testCircuit.nPublic {
+			writeG1(&c, mul1(lc(s, &delta)))
+		}
+	}
+	section(zkeySectionPointsA, a.Bytes())
+	section(zkeySectionPointsB1, b1.Bytes())
+	section(zkeySectionPointsB2, b2.Bytes())
+	section(zkeySectionPointsC, c.Bytes())
+
+	// H points are Lagrange basis of odd roots of unity of order 2n divided by delta
+	var deltaInv fr.Element
+	deltaInv.Inverse(&delta)
+	for i := uint64(0); i < n; i++ {
+		l := lagrange(2*n, 2*i+1, &tau)
+		writeG1(&hs, mul1(*l.Mul(&l, &deltaInv)))
+	}
+	section(zkeySectionPointsH, hs.Bytes())
+
+	g1JSON := func(p bn254.G1Affine) []string { return []string{p.X.String(), p.Y.String(), "1"} }
+	g2JSON := func(p bn254.G2Affine) [][]string {
+		return [][]string{{p.X.A0.String(), p.X.A1.String()}, {p.Y.A0.String(), p.Y.A1.String()}, {"1", "0"}}
+	}
+	vk := map[string]interface{}{
+		"protocol":   "groth16",
+		"curve":      "bn128",
+		"nPublic":    testCircuit.nPublic,
+		"vk_alpha_1": g1JSON(mul1(alpha)),
+		"vk_beta_2":  g2JSON(mul2(beta)),
+		"vk_gamma_2": g2JSON(mul2(gamma)),
+		"vk_delta_2": g2JSON(mul2(delta)),
+	}
+	var icJSON [][]string
+	for s := 0; s <= testCircuit.nPublic; s++ {
+		icJSON = append(icJSON, g1JSON(mul1(lc(s, &gamma))))
+	}
+	vk["IC"] = icJSON
+	vkey, err := json.Marshal(vk)
+	require.NoError(t, err)
+	return zk.Bytes(), vkey
+}
+
+// testWitness returns wtns file with signals of testCircuit after constant 1
+func testWitness(signals ...int64) []byte {
+	var values bytes.Buffer
+	for _, s := range append([]int64{1}, signals...) {
+		var e fr.Element
+		values.Write(leBytes(e.SetInt64(s).BigInt(new(big.Int))))
+	}
+
+	var header bytes.Buffer
+	writeLE(&header, uint32(fieldSize))
+	header.Write(leBytes(fr.Modulus()))
+	writeLE(&header, uint32(len(signals)+1))
+
+	var wtns bytes.Buffer
+	wtns.WriteString("wtns")
+	writeLE(&wtns, uint32(2), uint32(2))
+	writeLE(&wtns, uint32(wtnsSectionHeader), uint64(header.Len()))
+	wtns.Write(header.Bytes())
+	writeLE(&wtns, uint32(wtnsSectionValues), uint64(values.Len()))
+	wtns.Write(values.Bytes())
+	return wtns.Bytes()
+}
+
+// lagrange returns i-th Lagrange basis polynomial of roots of unity of order n at x
+func lagrange(n, i uint64, x *fr.Element) fr.Element {
+	omega, err := fft.Generator(n)
+	if err != nil {
+		panic(err)
+	}
+	var wi, num, den, l fr.Element
+	wi.Exp(omega, new(big.Int).SetUint64(i))
+	num.Exp(*x, new(big.Int).SetUint64(n)).Sub(&num, new(fr.Element).SetOne()).Mul(&num, &wi)
+	den.Sub(x, &wi).Mul(&den, new(fr.Element).SetUint64(n))
+	return *l.Div(&num, &den)
+}
+
+func writeG1(b *bytes.Buffer, p bn254.G1Affine) {
+	writeLE(b, [4]uint64(p.X), [4]uint64(p.Y))
+}
+
+func writeG2(b *bytes.Buffer, p bn254.G2Affine) {
+	writeLE(b, [4]uint64(p.X.A0), [4]uint64(p.X.A1), [4]uint64(p.Y.A0), [4]uint64(p.Y.A1))
+}
+
+func writeLE(b *bytes.Buffer, values ...interface{}) {
+	for _, v := range values {
+		_ = binary.Write(b, binary.LittleEndian, v)
+	}
+}
+
+func le32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, v)
+	return b
+}
@@ -0,0 +1,105 @@
+package proof
+
+import (
+	"context"
+	"sync"
+)
+
+// Phase is a stage of proof generation
+type Phase string
+
+const (
+	// PhaseWitness is calculation of the witness from inputs
+	PhaseWitness Phase = "witness"
+	// PhaseProof is generation of the proof from the witness
+	PhaseProof Phase = "proof"
+	// PhaseVerification is verification of the generated proof
+	PhaseVerification Phase = "verification"
+)
+
+// ProgressFunc is called when proof generation enters new phase
+type ProgressFunc func(phase Phase)
+
+type progressKey struct{}
+
+// WithProgress returns context which makes GenerateZkProof report its phases to fn
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// ReportProgress reports phase to function of context set by WithProgress
+func ReportProgress(ctx context.Context, phase Phase) {
+	if fn, ok := ctx.Value(progressKey{}).(ProgressFunc); ok {
+		fn(phase)
+	}
+}
+
+// ProgressGroup reports phases of computation shared by several callers to progress functions of all of them
+type ProgressGroup struct {
+	mu    sync.Mutex
+	calls map[string]*progressCall
+	seq   int
+}
+
+type progressCall struct {
+	phase     Phase
+	listeners map[int]ProgressFunc
+}
+
+// NewProgressGroup creates new instance of ProgressGroup
+func NewProgressGroup() *ProgressGroup {
+	return &ProgressGroup{calls: make(map[string]*progressCall)}
+}
+
+// Join subscribes progress function of ctx to phases of computation key, current phase of the computation
+// is reported to it at once. Call returned function to unsubscribe when the caller stops waiting.
+func (g *ProgressGroup) Join(ctx context.Context, key string) (leave func()) {
+	fn, ok := ctx.Value(progressKey{}).(ProgressFunc)
+	if !ok {
+		return func() {}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	c := g.call(key)
+	g.seq++
+	id := g.seq
+	c.listeners[id] = fn
+	if c.phase != "" {
+		fn(c.phase)
+	}
+
+	return func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		delete(c.listeners, id)
+		if len(c.listeners) == 0 && g.calls[key] == c {
+			delete(g.calls, key)
+		}
+	}
+}
+
+// WithProgress returns context which reports phases of computation key to all joined callers
+func (g *ProgressGroup) WithProgress(ctx context.Context, key string) context.Context {
+	return WithProgress(ctx, func(phase Phase) {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		c, ok := g.calls[key]
+		if !ok {
+			return
+		}
+		c.phase = phase
+		for _, fn := range c.listeners {
+			fn(phase)
+		}
+	})
+}
+
+func (g *ProgressGroup) call(key string) *progressCall {
+	c, ok := g.calls[key]
+	if !ok {
+		c = &progressCall{listeners: make(map[int]ProgressFunc)}
+		g.calls[key] = c
+	}
+	return c
+}
@@ -2,19 +2,21 @@ package proof
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
 	"path"
+	"path/filepath"
 
-	"github.com/iden3/go-rapidsnark/prover"
 	"github.com/iden3/go-rapidsnark/types"
 	"github.com/iden3/go-rapidsnark/verifier"
-	"github.com/iden3/go-rapidsnark/witness"
+	"github.com/iden3/prover-server/pkg/app/configs"
 	"github.com/iden3/prover-server/pkg/log"
 	"github.com/pkg/errors"
 )
 
+// ErrTimeout is returned when proof generation exceeds timeout of the circuit
+var ErrTimeout = errors.New("proof generation timed out")
+
 // ZKInputs are inputs for proof generation
 type ZKInputs map[string]interface{}
 
@@ -39,50 +41,57 @@ func GenerateZkProof(ctx context.Context, circuitPath string, inputs ZKInputs) (
 		return nil, fmt.Errorf("illegal circuitPath")
 	}
 
-	wasmBytes, err := os.ReadFile(circuitPath + "/circuit.wasm")
+	artifacts, err := LoadArtifacts(circuitPath)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to read wasm file")
+		return nil, err
 	}
 
-	calc, err := witness.NewCircom2WitnessCalculator(wasmBytes, true)
+	calc, err := NewWitnessCalculator(configs.WitnessConfig{})
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to instantiate wasm witness calc")
+		return nil, err
 	}
 
-	jsonInputs, err := json.Marshal(inputs)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to serialize inputs")
-	}
+	return Generate(ctx, calc, DefaultProver, artifacts, inputs)
+}
 
-	parsedInputs, err := witness.ParseInputs(jsonInputs)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to parse inputs")
+// Generate generates proof with circuit artifacts by witness calculator and prover and returns proof only if it's valid.
+// Context is checked between phases, calculator and prover may abort computation themselves.
+func Generate(ctx context.Context, calc WitnessCalculator, p Prover, artifacts *Artifacts, inputs ZKInputs) (*types.ZKProof, error) {
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
+	ReportProgress(ctx, PhaseWitness)
 
-	wtns, err := calc.CalculateWTNSBin(parsedInputs, true)
+	wtns, err := calc.CalculateWitness(ctx, artifacts.Wasm, inputs)
 	if err != nil {
 		log.WithContext(ctx).Errorw("failed to calculate witness", "error", err)
 		return nil, errors.Wrap(err, "failed to calculate witness")
 	}
 	log.WithContext(ctx).Debugw("-- witness calculate completed --")
 
-	zkeyBytes, err := os.ReadFile(circuitPath + "/circuit_final.zkey")
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to read zkey file")
+	if err = ctx.Err(); err != nil {
+		return nil, err
 	}
+	ReportProgress(ctx, PhaseProof)
 
-	proof, err := prover.Groth16Prover(zkeyBytes, wtns)
+	var proof *types.ZKProof
+	if fp, ok := p.(FileProver); ok && artifacts.ZKeyPath != "" {
+		proof, err = fp.ProveFile(ctx, artifacts.ZKeyPath, wtns)
+	} else {
+		proof, err = p.Prove(ctx, artifacts.ZKey, wtns)
+	}
 	if err != nil {
 		log.WithContext(ctx).Errorw("failed to generate proof", "proof", proof, "error", err)
 		return nil, errors.Wrap(err, "failed to generate proof")
 	}
 
-	vkeyBytes, err := os.ReadFile(circuitPath + "/verification_key.json")
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to read verification_key file")
+	if err = ctx.Err(); err != nil {
+		return nil, err
 	}
+	ReportProgress(ctx, PhaseVerification)
 
-	err = verifier.VerifyGroth16(*proof, vkeyBytes)
+	err = verifier.VerifyGroth16(*proof, artifacts.VerificationKey)
 	if err != nil {
 		log.WithContext(ctx).Errorw("failed to verify proof", "proof", proof, "error", err)
 		return nil, errors.Wrap(err, "failed to verify proof")
@@ -98,11 +107,17 @@ func VerifyZkProof(ctx context.Context, circuitPath string, zkp *FullProof) erro
 		return fmt.Errorf("illegal circuitPath")
 	}
 
-	vkeyBytes, err := os.ReadFile(circuitPath + "/verification_key.json")
+	vkeyBytes, err := os.ReadFile(filepath.Join(circuitPath, VerificationKeyFile))
 	if err != nil {
 		return errors.Wrap(err, "failed to read verification_key file")
 	}
 
+	return Verify(ctx, vkeyBytes, zkp)
+}
+
+// Verify verifies proof with verification key and returns if proof is valid
+func Verify(ctx context.Context, verificationKey []byte, zkp *FullProof) error {
+
 	proof := types.ZKProof{
 		Proof: &types.ProofData{
 			A: zkp.Proof.A,
@@ -112,7 +127,7 @@ func VerifyZkProof(ctx context.Context, circuitPath string, zkp *FullProof) erro
 		},
 		PubSignals: zkp.PubSignals,
 	}
-	err = verifier.VerifyGroth16(proof, vkeyBytes)
+	err := verifier.VerifyGroth16(proof, verificationKey)
 	if err != nil {
 		log.WithContext(ctx).Errorw("failed to verify proof", "proof", zkp, "error", err)
 		return errors.Wrap(err, "failed to verify proof")
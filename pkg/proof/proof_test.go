@@ -66,3 +66,52 @@ func TestGenerateZkProofStateTransition(t *testing.T) {
 	proofJSON, _ := json.Marshal(proof)
 	fmt.Println(string(proofJSON))
 }
+
+// witnessFunc is a witness calculator calling the function
+type witnessFunc func(ctx context.Context) ([]byte, error)
+
+func (f witnessFunc) CalculateWitness(ctx context.Context, _ []byte, _ ZKInputs) ([]byte, error) {
+	return f(ctx)
+}
+
+func TestGenerateChecksContextBetweenPhases(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var phases []Phase
+	ctx = WithProgress(ctx, func(phase Phase) { phases = append(phases, phase) })
+
+	// request is cancelled while witness is calculated, proof isn't generated
+	calc := witnessFunc(func(context.Context) ([]byte, error) {
+		cancel()
+		return []byte("wtns"), nil
+	})
+	_, err := Generate(ctx, calc, DefaultProver, &Artifacts{}, nil)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, []Phase{PhaseWitness}, phases)
+
+	_, err = Generate(ctx, calc, DefaultProver, &Artifacts{}, nil)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, []Phase{PhaseWitness}, phases)
+}
+
+func TestProgressGroup(t *testing.T) {
+	g := NewProgressGroup()
+	var first, second []Phase
+	leaveFirst := g.Join(WithProgress(context.Background(), func(phase Phase) { first = append(first, phase) }), "key")
+	// callers without progress function don't join
+	g.Join(context.Background(), "key")()
+
+	ctx := g.WithProgress(context.Background(), "key")
+	ReportProgress(ctx, PhaseWitness)
+	// caller joining shared computation gets its current phase
+	leaveSecond := g.Join(WithProgress(context.Background(), func(phase Phase) { second = append(second, phase) }), "key")
+	ReportProgress(ctx, PhaseProof)
+	leaveFirst()
+	ReportProgress(ctx, PhaseVerification)
+	leaveSecond()
+	ReportProgress(ctx, PhaseVerification)
+
+	require.Equal(t, []Phase{PhaseWitness, PhaseProof}, first)
+	require.Equal(t, []Phase{PhaseWitness, PhaseProof, PhaseVerification}, second)
+	require.Empty(t, g.calls)
+}
@@ -0,0 +1,121 @@
+package proof
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/iden3/go-rapidsnark/types"
+	"github.com/iden3/prover-server/pkg/app/configs"
+	"github.com/pkg/errors"
+)
+
+// Prover backends
+const (
+	// BackendRapidsnark generates proofs with rapidsnark native library linked with CGO
+	BackendRapidsnark = "rapidsnark"
+	// BackendBinary generates proofs with external rapidsnark executable run as a subprocess
+	BackendBinary = "binary"
+	// BackendGnark generates proofs in pure Go with gnark-crypto
+	BackendGnark = "gnark"
+)
+
+// Prover generates Groth16 proof with proving key (zkey) of the circuit and witness (wtns)
+type Prover interface {
+	Prove(ctx context.Context, zkey, wtns []byte) (*types.ZKProof, error)
+}
+
+// FileProver is a prover which reads proving key from zkey file itself, so zkey isn't read into memory
+// for circuits which have zkey file on disk
+type FileProver interface {
+	Prover
+	ProveFile(ctx context.Context, zkeyPath string, wtns []byte) (*types.ZKProof, error)
+}
+
+// NewProver creates prover of backend from config, rapidsnark is used if backend type is empty
+// (or gnark if the server is built without rapidsnark)
+func NewProver(config configs.ProverBackendConfig) (Prover, error) {
+	switch config.Type {
+	case "":
+		return NewProver(configs.ProverBackendConfig{Type: defaultBackend})
+	case BackendRapidsnark:
+		return newRapidsnarkProver()
+	case BackendBinary:
+		if config.Path == "" {
+			return nil, errors.New("binary prover requires path of executable")
+		}
+		return &BinaryProver{Path: config.Path}, nil
+	case BackendGnark:
+		return &GnarkProver{}, nil
+	default:
+		return nil, errors.Errorf("unknown prover backend %q", config.Type)
+	}
+}
+
+// BinaryProver generates proofs with rapidsnark executable, which is run as
+// "<path> <circuit.zkey> <witness.wtns> <proof.json> <public.json>". Process is killed when context is done.
+type BinaryProver struct {
+	Path string
+}
+
+// Prove writes proving key into temporary directory and generates proof with it, it's used for circuits
+// without zkey file on disk, e.g. loaded from archives
+func (p *BinaryProver) Prove(ctx context.Context, zkey, wtns []byte) (*types.ZKProof, error) {
+	dir, err := os.MkdirTemp("", "prover-")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create prover directory")
+	}
+	defer os.RemoveAll(dir)
+
+	zkeyPath := filepath.Join(dir, "circuit.zkey")
+	if err = os.WriteFile(zkeyPath, zkey, 0o600); err != nil {
+		return nil, errors.Wrap(err, "failed to write zkey")
+	}
+	return p.ProveFile(ctx, zkeyPath, wtns)
+}
+
+// ProveFile writes witness into temporary directory, runs prover with zkey file and reads proof it writes
+func (p *BinaryProver) ProveFile(ctx context.Context, zkeyPath string, wtns []byte) (*types.ZKProof, error) {
+	dir, err := os.MkdirTemp("", "prover-")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create prover directory")
+	}
+	defer os.RemoveAll(dir)
+
+	wtnsPath := filepath.Join(dir, "witness.wtns")
+	proofPath := filepath.Join(dir, "proof.json")
+	publicPath := filepath.Join(dir, "public.json")
+	if err = os.WriteFile(wtnsPath, wtns, 0o600); err != nil {
+		return nil, errors.Wrap(err, "failed to write witness")
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.Path, zkeyPath, wtnsPath, proofPath, publicPath)
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, errors.Wrapf(err, "prover failed: %s", bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	var zkp types.ZKProof
+	proofBytes, err := os.ReadFile(proofPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read proof")
+	}
+	if err = json.Unmarshal(proofBytes, &zkp.Proof); err != nil {
+		return nil, errors.Wrap(err, "failed to parse proof")
+	}
+	publicBytes, err := os.ReadFile(publicPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read public signals")
+	}
+	if err = json.Unmarshal(publicBytes, &zkp.PubSignals); err != nil {
+		return nil, errors.Wrap(err, "failed to parse public signals")
+	}
+	return &zkp, nil
+}
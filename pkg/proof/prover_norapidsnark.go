@@ -0,0 +1,15 @@
+//go:build norapidsnark
+
+package proof
+
+import "github.com/pkg/errors"
+
+// server built with norapidsnark tag doesn't require CGO and rapidsnark native library
+const defaultBackend = BackendGnark
+
+// DefaultProver is a prover used for circuits without configured backend
+var DefaultProver Prover = &GnarkProver{}
+
+func newRapidsnarkProver() (Prover, error) {
+	return nil, errors.New("rapidsnark prover isn't available in this build")
+}
@@ -0,0 +1,27 @@
+//go:build !norapidsnark
+
+package proof
+
+import (
+	"context"
+
+	"github.com/iden3/go-rapidsnark/prover"
+	"github.com/iden3/go-rapidsnark/types"
+)
+
+const defaultBackend = BackendRapidsnark
+
+// DefaultProver is a prover used for circuits without configured backend
+var DefaultProver Prover = RapidsnarkProver{}
+
+// RapidsnarkProver generates proofs with rapidsnark native library
+type RapidsnarkProver struct{}
+
+func newRapidsnarkProver() (Prover, error) {
+	return RapidsnarkProver{}, nil
+}
+
+// Prove generates proof
+func (RapidsnarkProver) Prove(_ context.Context, zkey, wtns []byte) (*types.ZKProof, error) {
+	return prover.Groth16Prover(zkey, wtns)
+}
@@ -0,0 +1,49 @@
+package proof
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/iden3/prover-server/pkg/app/configs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProver(t *testing.T) {
+	p, err := NewProver(configs.ProverBackendConfig{})
+	require.NoError(t, err)
+	require.IsType(t, DefaultProver, p)
+
+	_, err = NewProver(configs.ProverBackendConfig{Type: BackendBinary})
+	require.Error(t, err)
+	_, err = NewProver(configs.ProverBackendConfig{Type: "snarkjs"})
+	require.Error(t, err)
+}
+
+func TestBinaryProver(t *testing.T) {
+	// fake rapidsnark checks its inputs and writes proof and public signals
+	bin := filepath.Join(t.TempDir(), "prover")
+	require.NoError(t, os.WriteFile(bin, []byte(`#!/bin/sh
+[ "$(cat "$1")" = zkey ] && [ "$(cat "$2")" = wtns ] || { echo "bad inputs" >&2; exit 1; }
+echo '{"pi_a":["1","2","1"],"pi_b":[["1","2"],["3","4"],["1","0"]],"pi_c":["5","6","1"],"protocol":"groth16"}' > "$3"
+echo '["42"]' > "$4"
+`), 0o700))
+
+	p, err := NewProver(configs.ProverBackendConfig{Type: BackendBinary, Path: bin})
+	require.NoError(t, err)
+	zkp, err := p.Prove(context.Background(), []byte("zkey"), []byte("wtns"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"42"}, zkp.PubSignals)
+	require.Equal(t, []string{"5", "6", "1"}, zkp.Proof.C)
+
+	_, err = p.Prove(context.Background(), []byte("other"), []byte("wtns"))
+	require.ErrorContains(t, err, "bad inputs")
+
+	// zkey file on disk is passed to the prover as is
+	zkeyPath := filepath.Join(t.TempDir(), "circuit_final.zkey")
+	require.NoError(t, os.WriteFile(zkeyPath, []byte("zkey"), 0o600))
+	zkp, err = p.(FileProver).ProveFile(context.Background(), zkeyPath, []byte("wtns"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"42"}, zkp.PubSignals)
+}
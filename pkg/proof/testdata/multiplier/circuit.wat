@@ -0,0 +1,52 @@
+;; Witness calculator of testdata/multiplier with ABI of circom2 wasm: public out, sum and private x, y,
+;; out = x*y, sum = x+y. It computes only the lowest 64 bits of signals, so inputs must be below 2^32.
+;; circuit.wasm is compiled by wat2wasm circuit.wat
+(module
+  (memory 1)
+  ;; shared memory is at 0, witness at 64 (32 bytes per signal: 1, out, sum, x, y), prime at 256
+  (data (i32.const 256)
+    "\01\00\00\f0\93\f5\e1\43\91\70\b9\79\48\e8\33\28\5d\58\81\81\b6\45\50\b8\29\a0\31\e1\72\4e\64\30")
+  (func (export "init") (param i32)
+    (i32.store offset=64 (i32.const 0) (i32.const 1)))
+  (func (export "getFieldNumLen32") (result i32)
+    (i32.const 8))
+  (func (export "getRawPrime")
+    (i32.store offset=0 (i32.const 0) (i32.load offset=0 (i32.const 256)))
+    (i32.store offset=4 (i32.const 0) (i32.load offset=4 (i32.const 256)))
+    (i32.store offset=8 (i32.const 0) (i32.load offset=8 (i32.const 256)))
+    (i32.store offset=12 (i32.const 0) (i32.load offset=12 (i32.const 256)))
+    (i32.store offset=16 (i32.const 0) (i32.load offset=16 (i32.const 256)))
+    (i32.store offset=20 (i32.const 0) (i32.load offset=20 (i32.const 256)))
+    (i32.store offset=24 (i32.const 0) (i32.load offset=24 (i32.const 256)))
+    (i32.store offset=28 (i32.const 0) (i32.load offset=28 (i32.const 256))))
+  (func (export "readSharedRWMemory") (param i32) (result i32)
+    (i32.load (i32.mul (local.get 0) (i32.const 4))))
+  (func (export "writeSharedRWMemory") (param i32 i32)
+    (i32.store (i32.mul (local.get 0) (i32.const 4)) (local.get 1)))
+  ;; signal is x if the lower half of FNV-1a hash of its name is the one of "x", y otherwise
+  (func (export "setInputSignal") (param i32 i32 i32) (local i32)
+    (local.set 3 (select (i32.const 160) (i32.const 192) (i32.eq (local.get 1) (i32.const 0x86021707))))
+    (i32.store offset=0 (local.get 3) (i32.load offset=0 (i32.const 0)))
+    (i32.store offset=4 (local.get 3) (i32.load offset=4 (i32.const 0)))
+    (i32.store offset=8 (local.get 3) (i32.load offset=8 (i32.const 0)))
+    (i32.store offset=12 (local.get 3) (i32.load offset=12 (i32.const 0)))
+    (i32.store offset=16 (local.get 3) (i32.load offset=16 (i32.const 0)))
+    (i32.store offset=20 (local.get 3) (i32.load offset=20 (i32.const 0)))
+    (i32.store offset=24 (local.get 3) (i32.load offset=24 (i32.const 0)))
+    (i32.store offset=28 (local.get 3) (i32.load offset=28 (i32.const 0))))
+  (func (export "getInputSize") (result i32)
+    (i32.const 2))
+  (func (export "getWitnessSize") (result i32)
+    (i32.const 5))
+  (func (export "getWitness") (param i32) (local i32)
+    (i64.store offset=96 (i32.const 0) (i64.mul (i64.load offset=160 (i32.const 0)) (i64.load offset=192 (i32.const 0))))
+    (i64.store offset=128 (i32.const 0) (i64.add (i64.load offset=160 (i32.const 0)) (i64.load offset=192 (i32.const 0))))
+    (local.set 1 (i32.add (i32.mul (local.get 0) (i32.const 32)) (i32.const 64)))
+    (i32.store offset=0 (i32.const 0) (i32.load offset=0 (local.get 1)))
+    (i32.store offset=4 (i32.const 0) (i32.load offset=4 (local.get 1)))
+    (i32.store offset=8 (i32.const 0) (i32.load offset=8 (local.get 1)))
+    (i32.store offset=12 (i32.const 0) (i32.load offset=12 (local.get 1)))
+    (i32.store offset=16 (i32.const 0) (i32.load offset=16 (local.get 1)))
+    (i32.store offset=20 (i32.const 0) (i32.load offset=20 (local.get 1)))
+    (i32.store offset=24 (i32.const 0) (i32.load offset=24 (local.get 1)))
+    (i32.store offset=28 (i32.const 0) (i32.load offset=28 (local.get 1)))))
@@ -0,0 +1,329 @@
+package proof
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"hash/fnv"
+	"math/big"
+	"strings"
+
+	"github.com/iden3/prover-server/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// envModule is a wasm module "env" exporting memory of 2000 pages imported by circom wasm:
+// (module (memory (export "memory") 2000))
+var envModule = []byte{
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00,
+	0x05, 0x04, 0x01, 0x00, 0xd0, 0x0f,
+	0x07, 0x0a, 0x01, 0x06, 'm', 'e', 'm', 'o', 'r', 'y', 0x02, 0x00,
+}
+
+// circom runtime errors reported to exceptionHandler by code
+var circomExceptions = map[uint32]string{
+	1: "Signal not found",
+	2: "Too many signals set",
+	3: "Signal already set",
+	4: "Assert Failed",
+	5: "Not enough memory",
+	6: "Input signal array access exceeds the size",
+}
+
+// WazeroCalculator calculates witness by running circom2 wasm with wazero, which doesn't require
+// native libraries. Compiled wasm is cached, every calculation runs in a new module instance.
+type WazeroCalculator struct {
+	cache wazero.CompilationCache
+}
+
+// NewWazeroCalculator creates wazero witness calculator
+func NewWazeroCalculator() *WazeroCalculator {
+	return &WazeroCalculator{cache: wazero.NewCompilationCache()}
+}
+
+// CalculateWitness calculates witness, calculation is aborted when context is done
+func (c *WazeroCalculator) CalculateWitness(ctx context.Context, wasm []byte, inputs ZKInputs) ([]byte, error) {
+	signals, err := flattenInputs(inputs)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse inputs")
+	}
+
+	// circuit imports memory from "env" module, so every instance requires its own runtime
+	config := wazero.NewRuntimeConfig().WithCompilationCache(c.cache).WithCloseOnContextDone(true)
+	r := wazero.NewRuntimeWithConfig(ctx, config)
+	defer r.Close(ctx)
+
+	if _, err = r.InstantiateWithConfig(ctx, envModule, wazero.NewModuleConfig().WithName("env")); err != nil {
+		return nil, errors.Wrap(err, "failed to instantiate memory")
+	}
+	wc := &circomInstance{}
+	if err = wc.instantiateRuntime(ctx, r); err != nil {
+		return nil, errors.Wrap(err, "failed to instantiate circom runtime")
+	}
+	compiled, err := r.CompileModule(ctx, wasm)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to compile wasm")
+	}
+	if wc.mod, err = r.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().WithName("")); err != nil {
+		return nil, errors.Wrap(err, "failed to instantiate wasm")
+	}
+
+	wtns, err := wc.calculate(ctx, signals)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if wc.exception != nil {
+		return nil, wc.exception
+	}
+	return wtns, err
+}
+
+// circomInstance is an instance of circom2 wasm witness calculator
+type circomInstance struct {
+	mod       api.Module
+	n32       uint32
+	exception error
+	errStr    strings.Builder
+	msgStr    strings.Builder
+}
+
+// call calls exported function with i32 arguments and returns its i32 result if it has one
+func (wc *circomInstance) call(ctx context.Context, name string, args ...uint32) (uint32, error) {
+	fn := wc.mod.ExportedFunction(name)
+	if fn == nil {
+		return 0, errors.Errorf("wasm doesn't export %s", name)
+	}
+	params := make([]uint64, len(args))
+	for i, a := range args {
+		params[i] = uint64(a)
+	}
+	results, err := fn.Call(ctx, params...)
+	if err != nil {
+		return 0, err
+	}
+	if len(results) == 0 {
+		return 0, nil
+	}
+	return uint32(results[0]), nil
+}
+
+// readShared writes n32 words of shared memory, which are little-endian field element, into buffer
+func (wc *circomInstance) readShared(ctx context.Context, b *bytes.Buffer) error {
+	for j := uint32(0); j < wc.n32; j++ {
+		v, err := wc.call(ctx, "readSharedRWMemory", j)
+		if err != nil {
+			return err
+		}
+		_ = binary.Write(b, binary.LittleEndian, v)
+	}
+	return nil
+}
+
+func (wc *circomInstance) calculate(ctx context.Context, signals map[string][]*big.Int) ([]byte, error) {
+	var err error
+	if _, err = wc.call(ctx, "init", 1); err != nil {
+		return nil, err
+	}
+	if wc.n32, err = wc.call(ctx, "getFieldNumLen32"); err != nil {
+		return nil, err
+	}
+	var prime bytes.Buffer
+	if _, err = wc.call(ctx, "getRawPrime"); err != nil {
+		return nil, err
+	}
+	if err = wc.readShared(ctx, &prime); err != nil {
+		return nil, err
+	}
+	p := new(big.Int).SetBytes(reversed(prime.Bytes()))
+
+	// getInputSignalSize is missing in wasm of circom prior to v2.0.4
+	checkSize := wc.mod.ExportedFunction("getInputSignalSize") != nil
+	var inputCounter uint32
+	for name, values := range signals {
+		h := fnv.New64a()
+		h.Write([]byte(name))
+		hash := h.Sum64()
+		hMSB, hLSB := uint32(hash>>32), uint32(hash)
+		if checkSize {
+			size, err := wc.call(ctx, "getInputSignalSize", hMSB, hLSB)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case int32(size) < 0:
+				return nil, errors.Errorf("signal %s not found", name)
+			case len(values) < int(size):
+				return nil, errors.Errorf("not enough values for input signal %s", name)
+			case len(values) > int(size):
+				return nil, errors.Errorf("too many values for input signal %s", name)
+			}
+		}
+		for i, v := range values {
+			v = new(big.Int).Mod(v, p)
+			if v.BitLen() > int(32*wc.n32) {
+				return nil, errors.Errorf("illegal value of input signal %s", name)
+			}
+			le := reversed(v.FillBytes(make([]byte, 4*wc.n32)))
+			for j := uint32(0); j < wc.n32; j++ {
+				if _, err = wc.call(ctx, "writeSharedRWMemory", j, binary.LittleEndian.Uint32(le[4*j:])); err != nil {
+					return nil, err
+				}
+			}
+			if _, err = wc.call(ctx, "setInputSignal", hMSB, hLSB, uint32(i)); err != nil {
+				return nil, err
+			}
+			inputCounter++
+		}
+	}
+	inputSize, err := wc.call(ctx, "getInputSize")
+	if err != nil {
+		return nil, err
+	}
+	if inputCounter < inputSize {
+		return nil, errors.Errorf("not all inputs have been set: only %d out of %d", inputCounter, inputSize)
+	}
+
+	witnessSize, err := wc.call(ctx, "getWitnessSize")
+	if err != nil {
+		return nil, err
+	}
+	n8 := 4 * wc.n32
+	var wtns bytes.Buffer
+	wtns.Grow(int(44 + n8 + witnessSize*n8))
+	wtns.Write(wtnsMagic)
+	_ = binary.Write(&wtns, binary.LittleEndian, []uint32{2, 2, wtnsSectionHeader})
+	_ = binary.Write(&wtns, binary.LittleEndian, uint64(8+n8))
+	_ = binary.Write(&wtns, binary.LittleEndian, n8)
+	wtns.Write(prime.Bytes())
+	_ = binary.Write(&wtns, binary.LittleEndian, []uint32{witnessSize, wtnsSectionValues})
+	_ = binary.Write(&wtns, binary.LittleEndian, uint64(n8)*uint64(witnessSize))
+	for i := uint32(0); i < witnessSize; i++ {
+		if _, err = wc.call(ctx, "getWitness", i); err != nil {
+			return nil, err
+		}
+		if err = wc.readShared(ctx, &wtns); err != nil {
+			return nil, err
+		}
+	}
+	return wtns.Bytes(), nil
+}
+
+// instantiateRuntime instantiates "runtime" module with functions imported by circom wasm
+func (wc *circomInstance) instantiateRuntime(ctx context.Context, r wazero.Runtime) error {
+	_, err := r.NewHostModuleBuilder("runtime").
+		NewFunctionBuilder().WithFunc(func(_ context.Context, code uint32) {
+		msg, ok := circomExceptions[code]
+		if !ok {
+			msg = "Unknown error"
+		}
+		if wc.errStr.Len() > 0 {
+			msg += ".\n" + wc.errStr.String()
+		}
+		wc.exception = errors.New(msg)
+	}).Export("exceptionHandler").
+		NewFunctionBuilder().WithFunc(func(ctx context.Context) {
+		var b bytes.Buffer
+		if err := wc.readShared(ctx, &b); err != nil {
+			return
+		}
+		if wc.msgStr.Len() > 0 {
+			wc.msgStr.WriteString(" ")
+		}
+		wc.msgStr.WriteString(new(big.Int).SetBytes(reversed(b.Bytes())).String())
+	}).Export("showSharedRWMemory").
+		NewFunctionBuilder().WithFunc(func(context.Context) {}).Export("log").
+		NewFunctionBuilder().WithFunc(func(ctx context.Context) {
+		wc.errStr.WriteString(wc.message(ctx) + "\n")
+	}).Export("printErrorMessage").
+		NewFunctionBuilder().WithFunc(func(ctx context.Context) {
+		// log() of circuit ends with "\n" message
+		msg := wc.message(ctx)
+		if msg == "\n" {
+			log.WithContext(ctx).Debugw("circuit log", "message", wc.msgStr.String())
+			wc.msgStr.Reset()
+			return
+		}
+		if wc.msgStr.Len() > 0 {
+			wc.msgStr.WriteString(" ")
+		}
+		wc.msgStr.WriteString(msg)
+	}).Export("writeBufferMessage").
+		Instantiate(ctx)
+	return err
+}
+
+// message reads message of circuit by chars
+func (wc *circomInstance) message(ctx context.Context) string {
+	var b strings.Builder
+	for {
+		c, err := wc.call(ctx, "getMessageChar")
+		if err != nil || c == 0 {
+			return b.String()
+		}
+		b.WriteRune(rune(c))
+	}
+}
+
+// flattenInputs parses values of input signals, which are numbers, decimal or hex strings and nested arrays
+func flattenInputs(inputs ZKInputs) (map[string][]*big.Int, error) {
+	jsonInputs, err := json.Marshal(inputs)
+	if err != nil {
+		return nil, err
+	}
+	d := json.NewDecoder(bytes.NewReader(jsonInputs))
+	d.UseNumber()
+	var raw map[string]interface{}
+	if err = d.Decode(&raw); err != nil {
+		return nil, err
+	}
+	signals := make(map[string][]*big.Int, len(raw))
+	for name, v := range raw {
+		if signals[name], err = flattenInput(nil, v); err != nil {
+			return nil, errors.Wrapf(err, "input %s", name)
+		}
+	}
+	return signals, nil
+}
+
+func flattenInput(acc []*big.Int, v interface{}) ([]*big.Int, error) {
+	switch v := v.(type) {
+	case []interface{}:
+		var err error
+		for _, e := range v {
+			if acc, err = flattenInput(acc, e); err != nil {
+				return nil, err
+			}
+		}
+		return acc, nil
+	case string:
+		n, ok := new(big.Int).SetString(v, 0)
+		if !ok {
+			return nil, errors.Errorf("illegal number %q", v)
+		}
+		return append(acc, n), nil
+	case json.Number:
+		n, ok := new(big.Int).SetString(v.String(), 10)
+		if !ok {
+			f, err := v.Float64()
+			if err != nil {
+				return nil, errors.Errorf("illegal number %s", v)
+			}
+			n = big.NewInt(int64(f))
+		}
+		return append(acc, n), nil
+	default:
+		return nil, errors.Errorf("unexpected type %T", v)
+	}
+}
+
+// reversed returns bytes in reverse order
+func reversed(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i := range b {
+		r[len(b)-1-i] = b[i]
+	}
+	return r
+}
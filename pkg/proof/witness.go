@@ -0,0 +1,92 @@
+package proof
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/iden3/prover-server/pkg/app/configs"
+	"github.com/pkg/errors"
+)
+
+// Witness calculator engines
+const (
+	// WitnessWasmer runs circuit wasm with wasmer, it requires wasmer shared library
+	WitnessWasmer = "wasmer"
+	// WitnessWazero runs circuit wasm with wazero runtime in pure Go
+	WitnessWazero = "wazero"
+	// WitnessBinary runs native witness generator of the circuit compiled from circom C++ code
+	WitnessBinary = "binary"
+)
+
+// WitnessCalculator calculates witness of the circuit (wtns) for inputs with circuit wasm
+type WitnessCalculator interface {
+	CalculateWitness(ctx context.Context, wasm []byte, inputs ZKInputs) ([]byte, error)
+}
+
+// NewWitnessCalculator creates witness calculator of engine from config, wasmer is used if engine type is empty
+// (or wazero if the server is built without wasmer)
+func NewWitnessCalculator(config configs.WitnessConfig) (WitnessCalculator, error) {
+	switch config.Type {
+	case "":
+		return NewWitnessCalculator(configs.WitnessConfig{Type: defaultWitnessEngine})
+	case WitnessWasmer:
+		return newWasmerCalculator()
+	case WitnessWazero:
+		return NewWazeroCalculator(), nil
+	case WitnessBinary:
+		if config.Path == "" {
+			return nil, errors.New("binary witness calculator requires path of executable")
+		}
+		return &BinaryWitnessCalculator{Path: config.Path}, nil
+	default:
+		return nil, errors.Errorf("unknown witness calculator %q", config.Type)
+	}
+}
+
+// BinaryWitnessCalculator calculates witness with native witness generator of the circuit built from
+// circom --c output, which is run as "<path> <input.json> <witness.wtns>". Circuit wasm isn't used, but
+// generator requires its .dat file next to executable. Process is killed when context is done.
+type BinaryWitnessCalculator struct {
+	Path string
+}
+
+// CalculateWitness writes inputs into temporary directory, runs generator and reads witness it writes
+func (c *BinaryWitnessCalculator) CalculateWitness(ctx context.Context, _ []byte, inputs ZKInputs) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "witness-")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create witness directory")
+	}
+	defer os.RemoveAll(dir)
+
+	inputsPath := filepath.Join(dir, "input.json")
+	wtnsPath := filepath.Join(dir, "witness.wtns")
+	jsonInputs, err := json.Marshal(inputs)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to serialize inputs")
+	}
+	if err = os.WriteFile(inputsPath, jsonInputs, 0o600); err != nil {
+		return nil, errors.Wrap(err, "failed to write inputs")
+	}
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.Path, inputsPath, wtnsPath)
+	// circom generators print failed assertions to stdout
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err = cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, errors.Wrapf(err, "witness generator failed: %s", bytes.TrimSpace(output.Bytes()))
+	}
+
+	wtns, err := os.ReadFile(wtnsPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read witness")
+	}
+	return wtns, nil
+}
@@ -0,0 +1,12 @@
+//go:build nowasmer
+
+package proof
+
+import "github.com/pkg/errors"
+
+// server built with nowasmer tag doesn't depend on wasmer shared library
+const defaultWitnessEngine = WitnessWazero
+
+func newWasmerCalculator() (WitnessCalculator, error) {
+	return nil, errors.New("wasmer witness calculator isn't available in this build")
+}
@@ -0,0 +1,61 @@
+package proof
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/iden3/prover-server/pkg/app/configs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWitnessCalculator(t *testing.T) {
+	c, err := NewWitnessCalculator(configs.WitnessConfig{Type: WitnessWazero})
+	require.NoError(t, err)
+	require.IsType(t, &WazeroCalculator{}, c)
+
+	_, err = NewWitnessCalculator(configs.WitnessConfig{Type: WitnessBinary})
+	require.Error(t, err)
+	_, err = NewWitnessCalculator(configs.WitnessConfig{Type: "snarkjs"})
+	require.Error(t, err)
+}
+
+func TestBinaryWitnessCalculator(t *testing.T) {
+	// fake generator checks inputs and writes witness
+	bin := filepath.Join(t.TempDir(), "circuit")
+	require.NoError(t, os.WriteFile(bin, []byte(`#!/bin/sh
+[ "$(cat "$1")" = '{"a":"1"}' ] || { echo "Assert Failed"; exit 1; }
+printf wtns > "$2"
+`), 0o700))
+
+	c, err := NewWitnessCalculator(configs.WitnessConfig{Type: WitnessBinary, Path: bin})
+	require.NoError(t, err)
+	wtns, err := c.CalculateWitness(context.Background(), nil, ZKInputs{"a": "1"})
+	require.NoError(t, err)
+	require.Equal(t, []byte("wtns"), wtns)
+
+	_, err = c.CalculateWitness(context.Background(), nil, ZKInputs{"a": "2"})
+	require.ErrorContains(t, err, "Assert Failed")
+}
+
+func TestFlattenInputs(t *testing.T) {
+	signals, err := flattenInputs(ZKInputs{
+		"a": "0x10",
+		"b": []interface{}{[]string{"1", "2"}, []interface{}{3, "21888242871839275222246405745257275088548364400416422868235621858902346362432"}},
+		"c": -1,
+	})
+	require.NoError(t, err)
+	n, _ := new(big.Int).SetString("21888242871839275222246405745257275088548364400416422868235621858902346362432", 10)
+	require.Equal(t, map[string][]*big.Int{
+		"a": {big.NewInt(16)},
+		"b": {big.NewInt(1), big.NewInt(2), big.NewInt(3), n},
+		"c": {big.NewInt(-1)},
+	}, signals)
+
+	_, err = flattenInputs(ZKInputs{"a": "x"})
+	require.Error(t, err)
+	_, err = flattenInputs(ZKInputs{"a": true})
+	require.Error(t, err)
+}
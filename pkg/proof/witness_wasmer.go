@@ -0,0 +1,40 @@
+//go:build !nowasmer
+
+package proof
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/iden3/go-rapidsnark/witness"
+	"github.com/pkg/errors"
+)
+
+const defaultWitnessEngine = WitnessWasmer
+
+// WasmerCalculator calculates witness with circom2 witness calculator of go-rapidsnark running on wasmer
+type WasmerCalculator struct{}
+
+func newWasmerCalculator() (WitnessCalculator, error) {
+	return WasmerCalculator{}, nil
+}
+
+// CalculateWitness calculates witness
+func (WasmerCalculator) CalculateWitness(_ context.Context, wasm []byte, inputs ZKInputs) ([]byte, error) {
+	calc, err := witness.NewCircom2WitnessCalculator(wasm, true)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to instantiate wasm witness calc")
+	}
+
+	jsonInputs, err := json.Marshal(inputs)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to serialize inputs")
+	}
+
+	parsedInputs, err := witness.ParseInputs(jsonInputs)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse inputs")
+	}
+
+	return calc.CalculateWTNSBin(parsedInputs, true)
+}
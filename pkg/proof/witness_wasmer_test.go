@@ -0,0 +1,43 @@
+//go:build !nowasmer
+
+package proof
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWazeroMatchesWasmer(t *testing.T) {
+	// testdata/circom2 is auth circuit compiled by circom 2.0 (fixture of go-rapidsnark witness package)
+	gz, err := os.ReadFile("testdata/circom2/circuit.wasm.gz")
+	require.NoError(t, err)
+	r, err := gzip.NewReader(bytes.NewReader(gz))
+	require.NoError(t, err)
+	wasm, err := io.ReadAll(r)
+	require.NoError(t, err)
+	data, err := os.ReadFile("testdata/circom2/input.json")
+	require.NoError(t, err)
+	var inputs ZKInputs
+	require.NoError(t, json.Unmarshal(data, &inputs))
+
+	ctx := context.Background()
+	expected, err := WasmerCalculator{}.CalculateWitness(ctx, wasm, inputs)
+	require.NoError(t, err)
+	wtns, err := NewWazeroCalculator().CalculateWitness(ctx, wasm, inputs)
+	require.NoError(t, err)
+	require.Equal(t, expected, wtns)
+
+	// signal of wrong size fails in both runtimes
+	inputs["challenge"] = []string{"1", "2"}
+	_, err = WasmerCalculator{}.CalculateWitness(ctx, wasm, inputs)
+	require.Error(t, err)
+	_, err = NewWazeroCalculator().CalculateWitness(ctx, wasm, inputs)
+	require.Error(t, err)
+}
@@ -0,0 +1,279 @@
+package proof
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/fft"
+	"github.com/pkg/errors"
+)
+
+// Sections of zkey file
+const (
+	zkeySectionHeader        = 1
+	zkeySectionGroth16Header = 2
+	zkeySectionCoefs         = 4
+	zkeySectionPointsA       = 5
+	zkeySectionPointsB1      = 6
+	zkeySectionPointsB2      = 7
+	zkeySectionPointsC       = 8
+	zkeySectionPointsH       = 9
+)
+
+// Sections of wtns file
+const (
+	wtnsSectionHeader = 1
+	wtnsSectionValues = 2
+)
+
+const (
+	zkeyProtocolGroth16 = 1
+	// fieldSize is a size of bn254 base and scalar field elements in zkey and wtns files
+	fieldSize = 32
+	g1Size    = 2 * fieldSize
+	g2Size    = 4 * fieldSize
+	// coefSize is a size of matrix, constraint and signal numbers and value of coefficient
+	coefSize = 12 + fieldSize
+)
+
+var (
+	wtnsMagic = []byte("wtns")
+	// montR is 2^256 mod r, Montgomery form of scalar is its value multiplied by montR
+	montR fr.Element
+	// montRInv is an inverse of montR
+	montRInv fr.Element
+)
+
+func init() {
+	montR.SetBigInt(new(big.Int).Lsh(big.NewInt(1), 256))
+	montRInv.Inverse(&montR)
+}
+
+// provingKey is Groth16 proving key of bn254 circuit read from zkey file of snarkjs
+type provingKey struct {
+	nVars   int
+	nPublic int
+	domain  *fft.Domain
+
+	alpha1 bn254.G1Affine
+	beta1  bn254.G1Affine
+	beta2  bn254.G2Affine
+	delta1 bn254.G1Affine
+	delta2 bn254.G2Affine
+
+	coefs []zkeyCoef
+	a     []bn254.G1Affine
+	b1    []bn254.G1Affine
+	b2    []bn254.G2Affine
+	c     []bn254.G1Affine
+	h     []bn254.G1Affine
+}
+
+// zkeyCoef is a coefficient of signal in constraint of matrix A (0) or B (1)
+type zkeyCoef struct {
+	matrix     uint32
+	constraint uint32
+	signal     uint32
+	value      fr.Element
+}
+
+// readSections returns sections of iden3 binary file (zkey, wtns) by type
+func readSections(data, magic []byte) (map[uint32][]byte, error) {
+	if len(data) < 12 || !bytes.Equal(data[:4], magic) {
+		return nil, errors.Errorf("not a %s file", magic)
+	}
+	nSections := binary.LittleEndian.Uint32(data[8:12])
+	sections := make(map[uint32][]byte, nSections)
+	pos := uint64(12)
+	for i := uint32(0); i < nSections; i++ {
+		if uint64(len(data)) < pos+12 {
+			return nil, errors.Errorf("truncated %s file", magic)
+		}
+		typ := binary.LittleEndian.Uint32(data[pos:])
+		size := binary.LittleEndian.Uint64(data[pos+4:])
+		pos += 12
+		if uint64(len(data))-pos < size {
+			return nil, errors.Errorf("truncated %s file", magic)
+		}
+		sections[typ] = data[pos : pos+size]
+		pos += size
+	}
+	return sections, nil
+}
+
+// parseZKey reads Groth16 proving key of bn254 circuit from zkey file
+func parseZKey(zkey []byte) (*provingKey, error) {
+	sections, err := readSections(zkey, zkeyMagic)
+	if err != nil {
+		return nil, err
+	}
+	header := sections[zkeySectionHeader]
+	if len(header) < 4 || binary.LittleEndian.Uint32(header) != zkeyProtocolGroth16 {
+		return nil, errors.New("zkey isn't groth16 proving key")
+	}
+
+	h := sections[zkeySectionGroth16Header]
+	if len(h) < 4+fieldSize+4+fieldSize+12+4*g1Size+2*g2Size ||
+		binary.LittleEndian.Uint32(h) != fieldSize || !bytes.Equal(h[4:4+fieldSize], leBytes(fp.Modulus())) ||
+		binary.LittleEndian.Uint32(h[4+fieldSize:]) != fieldSize ||
+		!bytes.Equal(h[8+fieldSize:8+2*fieldSize], leBytes(fr.Modulus())) {
+		return nil, errors.New("zkey isn't bn254 proving key")
+	}
+	h = h[8+2*fieldSize:]
+	pk := &provingKey{
+		nVars:   int(binary.LittleEndian.Uint32(h)),
+		nPublic: int(binary.LittleEndian.Uint32(h[4:])),
+	}
+	domainSize := uint64(binary.LittleEndian.Uint32(h[8:]))
+	if domainSize == 0 || domainSize&(domainSize-1) != 0 || pk.nPublic >= pk.nVars {
+		return nil, errors.New("illegal zkey header")
+	}
+	// H is evaluated on coset of roots of unity of order 2*domainSize
+	shift, err := fft.Generator(2 * domainSize)
+	if err != nil {
+		return nil, errors.Wrap(err, "domain of zkey is too large")
+	}
+	pk.domain = fft.NewDomain(domainSize, shift)
+
+	h = h[12:]
+	pk.alpha1 = g1FromLEM(h)
+	pk.beta1 = g1FromLEM(h[g1Size:])
+	pk.beta2 = g2FromLEM(h[2*g1Size:])
+	pk.delta1 = g1FromLEM(h[2*g1Size+2*g2Size:])
+	pk.delta2 = g2FromLEM(h[3*g1Size+2*g2Size:])
+
+	if pk.coefs, err = parseCoefs(sections[zkeySectionCoefs], pk.nVars, domainSize); err != nil {
+		return nil, err
+	}
+	if pk.a, err = g1Section(sections[zkeySectionPointsA], pk.nVars); err != nil {
+		return nil, errors.Wrap(err, "points A")
+	}
+	if pk.b1, err = g1Section(sections[zkeySectionPointsB1], pk.nVars); err != nil {
+		return nil, errors.Wrap(err, "points B1")
+	}
+	if pk.b2, err = g2Section(sections[zkeySectionPointsB2], pk.nVars); err != nil {
+		return nil, errors.Wrap(err, "points B2")
+	}
+	if pk.c, err = g1Section(sections[zkeySectionPointsC], pk.nVars-pk.nPublic-1); err != nil {
+		return nil, errors.Wrap(err, "points C")
+	}
+	if pk.h, err = g1Section(sections[zkeySectionPointsH], int(domainSize)); err != nil {
+		return nil, errors.Wrap(err, "points H")
+	}
+	return pk, nil
+}
+
+// parseCoefs reads coefficients of matrices A and B, values are stored multiplied by montR^2
+func parseCoefs(section []byte, nVars int, domainSize uint64) ([]zkeyCoef, error) {
+	if len(section) < 4 {
+		return nil, errors.New("missing coefficients in zkey")
+	}
+	n := int(binary.LittleEndian.Uint32(section))
+	if len(section)-4 != n*coefSize {
+		return nil, errors.New("illegal size of coefficients in zkey")
+	}
+	coefs := make([]zkeyCoef, n)
+	for i := range coefs {
+		b := section[4+i*coefSize:]
+		c := &coefs[i]
+		c.matrix = binary.LittleEndian.Uint32(b)
+		c.constraint = binary.LittleEndian.Uint32(b[4:])
+		c.signal = binary.LittleEndian.Uint32(b[8:])
+		if c.matrix > 1 || uint64(c.constraint) >= domainSize || int(c.signal) >= nVars {
+			return nil, errors.New("illegal coefficient in zkey")
+		}
+		// raw limbs are the value multiplied by montR in Montgomery form
+		c.value = fr.Element(limbs(b[12:]))
+		c.value.Mul(&c.value, &montRInv)
+	}
+	return coefs, nil
+}
+
+// parseWitness reads values of signals from wtns file
+func parseWitness(wtns []byte) ([]fr.Element, error) {
+	sections, err := readSections(wtns, wtnsMagic)
+	if err != nil {
+		return nil, err
+	}
+	header := sections[wtnsSectionHeader]
+	if len(header) < 4+fieldSize+4 || binary.LittleEndian.Uint32(header) != fieldSize ||
+		!bytes.Equal(header[4:4+fieldSize], leBytes(fr.Modulus())) {
+		return nil, errors.New("witness isn't bn254 witness")
+	}
+	n := int(binary.LittleEndian.Uint32(header[4+fieldSize:]))
+	values := sections[wtnsSectionValues]
+	if len(values) != n*fieldSize {
+		return nil, errors.New("illegal size of witness")
+	}
+
+	w := make([]fr.Element, n)
+	for i := range w {
+		// raw limbs are the value divided by montR in Montgomery form
+		w[i] = fr.Element(limbs(values[i*fieldSize:]))
+		w[i].Mul(&w[i], &montR)
+	}
+	return w, nil
+}
+
+func g1Section(section []byte, n int) ([]bn254.G1Affine, error) {
+	if len(section) != n*g1Size {
+		return nil, errors.New("illegal size of section")
+	}
+	points := make([]bn254.G1Affine, n)
+	for i := range points {
+		points[i] = g1FromLEM(section[i*g1Size:])
+	}
+	return points, nil
+}
+
+func g2Section(section []byte, n int) ([]bn254.G2Affine, error) {
+	if len(section) != n*g2Size {
+		return nil, errors.New("illegal size of section")
+	}
+	points := make([]bn254.G2Affine, n)
+	for i := range points {
+		points[i] = g2FromLEM(section[i*g2Size:])
+	}
+	return points, nil
+}
+
+// g1FromLEM reads affine point with coordinates in little-endian Montgomery form, zeros are point at infinity
+func g1FromLEM(b []byte) bn254.G1Affine {
+	return bn254.G1Affine{
+		X: fp.Element(limbs(b)),
+		Y: fp.Element(limbs(b[fieldSize:])),
+	}
+}
+
+func g2FromLEM(b []byte) bn254.G2Affine {
+	var p bn254.G2Affine
+	p.X.A0 = fp.Element(limbs(b))
+	p.X.A1 = fp.Element(limbs(b[fieldSize:]))
+	p.Y.A0 = fp.Element(limbs(b[2*fieldSize:]))
+	p.Y.A1 = fp.Element(limbs(b[3*fieldSize:]))
+	return p
+}
+
+// limbs returns little-endian 64-bit words of 32 bytes little-endian number
+func limbs(b []byte) [4]uint64 {
+	return [4]uint64{
+		binary.LittleEndian.Uint64(b),
+		binary.LittleEndian.Uint64(b[8:]),
+		binary.LittleEndian.Uint64(b[16:]),
+		binary.LittleEndian.Uint64(b[24:]),
+	}
+}
+
+// leBytes returns 32 bytes little-endian representation of the number
+func leBytes(n *big.Int) []byte {
+	b := make([]byte, fieldSize)
+	n.FillBytes(b)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return b
+}
@@ -0,0 +1,176 @@
+package scheduler
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Budget admits jobs while sum of their memory estimates stays within the limit and number of running jobs stays
+// within concurrency, other jobs wait in lanes of their priority. Higher lanes are served first, jobs of a lane are
+// admitted in order of arrival, so that small jobs don't starve large ones. Job waiting longer than max wait is
+// served ahead of higher lanes, so that lower lanes aren't starved either.
+type Budget struct {
+	mu          sync.Mutex
+	limit       int64
+	concurrency int
+	maxWait     time.Duration
+	now         func() time.Time
+	used        int64
+	running     int
+	// waiters are queues of lanes by rank
+	waiters []list.List
+}
+
+// Stats are usage of the budget
+type Stats struct {
+	LimitBytes  int64 `json:"limit_bytes,omitempty"`
+	UsedBytes   int64 `json:"used_bytes"`
+	Concurrency int   `json:"concurrency,omitempty"`
+	Running     int   `json:"running"`
+	Queued      int   `json:"queued"`
+	// Lanes are numbers of jobs queued in lanes
+	Lanes map[Priority]int `json:"lanes"`
+}
+
+type waiter struct {
+	cost     int64
+	ready    chan struct{}
+	enqueued time.Time
+}
+
+// NewBudget creates budget of limit bytes, memory isn't limited if limit is 0
+func NewBudget(limit int64) *Budget {
+	return &Budget{limit: limit, now: time.Now, waiters: make([]list.List, len(lanes))}
+}
+
+// WithConcurrency limits number of jobs running in parallel, 0 is no limit
+func (b *Budget) WithConcurrency(n int) *Budget {
+	b.concurrency = n
+	return b
+}
+
+// WithMaxWait makes jobs waiting longer than d served ahead of higher lanes, 0 disables it
+func (b *Budget) WithMaxWait(d time.Duration) *Budget {
+	b.maxWait = d
+	return b
+}
+
+// Acquire waits until job of cost bytes fits into the budget and returns function releasing it. Job which exceeds
+// the whole budget is admitted when no other jobs are running. Job is queued in the lane of priority from context,
+// normal lane is used if context has no priority.
+func (b *Budget) Acquire(ctx context.Context, cost int64) (release func(), err error) {
+	if b.limit > 0 && cost > b.limit {
+		cost = b.limit
+	}
+	if cost < 0 {
+		cost = 0
+	}
+	p, ok := PriorityFromContext(ctx)
+	if !ok {
+		p = PriorityNormal
+	}
+
+	b.mu.Lock()
+	w := &waiter{cost: cost, ready: make(chan struct{}), enqueued: b.now()}
+	lane := &b.waiters[p.Rank()]
+	elem := lane.PushBack(w)
+	b.admit()
+	b.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return b.releaseFunc(cost), nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		select {
+		case <-w.ready:
+			// admitted concurrently with cancellation
+			b.mu.Unlock()
+			b.release(cost)
+		default:
+			lane.Remove(elem)
+			// jobs behind the removed one may fit now
+			b.admit()
+			b.mu.Unlock()
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// Stats returns usage of the budget
+func (b *Budget) Stats() Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stats := Stats{
+		LimitBytes:  b.limit,
+		UsedBytes:   b.used,
+		Concurrency: b.concurrency,
+		Running:     b.running,
+		Lanes:       make(map[Priority]int, len(lanes)),
+	}
+	for i, p := range lanes {
+		stats.Lanes[p] = b.waiters[i].Len()
+		stats.Queued += b.waiters[i].Len()
+	}
+	return stats
+}
+
+func (b *Budget) releaseFunc(cost int64) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() { b.release(cost) })
+	}
+}
+
+func (b *Budget) release(cost int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.used -= cost
+	b.running--
+	b.admit()
+}
+
+// admit admits waiting jobs from the front of the queue while they fit into the budget
+func (b *Budget) admit() {
+	for {
+		lane := b.next()
+		if lane == nil {
+			return
+		}
+		front := lane.Front()
+		w := front.Value.(*waiter)
+		if b.limit > 0 && b.used+w.cost > b.limit || b.concurrency > 0 && b.running >= b.concurrency {
+			return
+		}
+		b.used += w.cost
+		b.running++
+		lane.Remove(front)
+		close(w.ready)
+	}
+}
+
+// next returns lane of the job to admit next: lane of the longest waiting job if it waits longer than max wait,
+// or the highest non-empty lane
+func (b *Budget) next() *list.List {
+	var next *list.List
+	var oldest time.Time
+	for i := range b.waiters {
+		front := b.waiters[i].Front()
+		if front == nil {
+			continue
+		}
+		if next == nil {
+			next = &b.waiters[i]
+			if b.maxWait <= 0 {
+				return next
+			}
+		}
+		if enqueued := front.Value.(*waiter).enqueued; b.now().Sub(enqueued) > b.maxWait &&
+			(oldest.IsZero() || enqueued.Before(oldest)) {
+			next, oldest = &b.waiters[i], enqueued
+		}
+	}
+	return next
+}
@@ -0,0 +1,172 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/iden3/prover-server/pkg/app/configs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBudget(t *testing.T) {
+	b := NewBudget(100)
+	ctx := context.Background()
+
+	release60, err := b.Acquire(ctx, 60)
+	require.NoError(t, err)
+	release30, err := b.Acquire(ctx, 30)
+	require.NoError(t, err)
+	require.Equal(t, Stats{LimitBytes: 100, UsedBytes: 90, Running: 2, Lanes: map[Priority]int{
+		PriorityHigh: 0, PriorityNormal: 0, PriorityLow: 0,
+	}}, b.Stats())
+
+	// large job waits, and small job which would fit waits behind it
+	admitted := make(chan int64, 2)
+	for i, cost := range []int64{50, 10} {
+		go func(cost int64) {
+			release, err := b.Acquire(ctx, cost)
+			require.NoError(t, err)
+			admitted <- cost
+			defer release()
+			time.Sleep(10 * time.Millisecond)
+		}(cost)
+		require.Eventually(t, func() bool { return b.Stats().Queued == i+1 }, time.Second, time.Millisecond)
+	}
+	release60()
+	require.ElementsMatch(t, []int64{50, 10}, []int64{<-admitted, <-admitted})
+	release30()
+	release30()
+	require.Eventually(t, func() bool {
+		stats := b.Stats()
+		return stats.UsedBytes == 0 && stats.Running == 0 && stats.Queued == 0
+	}, time.Second, time.Millisecond)
+
+	// job exceeding the whole budget runs alone
+	releaseAll, err := b.Acquire(ctx, 1000)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), b.Stats().UsedBytes)
+
+	// cancelled job leaves the queue
+	cancelled, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = b.Acquire(cancelled, 1)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	releaseAll()
+	stats := b.Stats()
+	require.Zero(t, stats.UsedBytes)
+	require.Zero(t, stats.Queued)
+}
+
+func TestBudgetAdmitsBehindCancelledJob(t *testing.T) {
+	b := NewBudget(100)
+	ctx := context.Background()
+	release, err := b.Acquire(ctx, 50)
+	require.NoError(t, err)
+	defer release()
+
+	cancelled, cancel := context.WithCancel(ctx)
+	go func() { _, _ = b.Acquire(cancelled, 100) }()
+	require.Eventually(t, func() bool { return b.Stats().Queued == 1 }, time.Second, time.Millisecond)
+
+	admitted := make(chan struct{})
+	go func() {
+		release, err := b.Acquire(ctx, 50)
+		require.NoError(t, err)
+		defer release()
+		close(admitted)
+	}()
+	require.Eventually(t, func() bool { return b.Stats().Queued == 2 }, time.Second, time.Millisecond)
+
+	cancel()
+	select {
+	case <-admitted:
+	case <-time.After(time.Second):
+		t.Fatal("job behind cancelled one isn't admitted")
+	}
+}
+
+func TestBudgetLanes(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := NewBudget(0).WithConcurrency(1).WithMaxWait(time.Minute)
+	b.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	release, err := b.Acquire(ctx, 0)
+	require.NoError(t, err)
+
+	admitted := make(chan Priority, 4)
+	acquire := func(p Priority) {
+		release, err := b.Acquire(WithPriority(ctx, p), 0)
+		require.NoError(t, err)
+		admitted <- p
+		release()
+	}
+	go acquire(PriorityLow)
+	require.Eventually(t, func() bool { return b.Stats().Lanes[PriorityLow] == 1 }, time.Second, time.Millisecond)
+	go acquire(PriorityNormal)
+	go acquire(PriorityHigh)
+	require.Eventually(t, func() bool { return b.Stats().Queued == 3 }, time.Second, time.Millisecond)
+	require.Equal(t, map[Priority]int{PriorityHigh: 1, PriorityNormal: 1, PriorityLow: 1}, b.Stats().Lanes)
+
+	// higher lanes are served first
+	release()
+	require.Equal(t, []Priority{PriorityHigh, PriorityNormal, PriorityLow},
+		[]Priority{<-admitted, <-admitted, <-admitted})
+
+	// job waiting longer than max wait is served ahead of higher lanes
+	release, err = b.Acquire(ctx, 0)
+	require.NoError(t, err)
+	go acquire(PriorityLow)
+	require.Eventually(t, func() bool { return b.Stats().Queued == 1 }, time.Second, time.Millisecond)
+	b.mu.Lock()
+	now = now.Add(2 * time.Minute)
+	b.mu.Unlock()
+	go acquire(PriorityHigh)
+	require.Eventually(t, func() bool { return b.Stats().Queued == 2 }, time.Second, time.Millisecond)
+	release()
+	require.Equal(t, []Priority{PriorityLow, PriorityHigh}, []Priority{<-admitted, <-admitted})
+}
+
+func TestResolver(t *testing.T) {
+	r, err := NewResolver(configs.PriorityConfig{Clients: []configs.ClientPriorityConfig{
+		{ClientCN: "wallet", Priority: "high"},
+		{APIKey: "issuer-key", Priority: "low"},
+	}})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		requested, clientCN, apiKey string
+		priority                    Priority
+		ok                          bool
+	}{
+		{"", "", "", "", false},
+		{"low", "", "", PriorityLow, true},
+		// clients which aren't configured can't raise priority above the default one
+		{"high", "unknown", "", PriorityNormal, true},
+		{"high", "", "unknown-key", PriorityNormal, true},
+		{"", "wallet", "", PriorityHigh, true},
+		{"low", "wallet", "", PriorityLow, true},
+		// requested priority can't exceed priority of the client
+		{"high", "wallet", "issuer-key", PriorityLow, true},
+		{"", "", "issuer-key", PriorityLow, true},
+	} {
+		p, ok, err := r.Resolve(tc.requested, tc.clientCN, tc.apiKey)
+		require.NoError(t, err)
+		require.Equal(t, tc.priority, p, tc)
+		require.Equal(t, tc.ok, ok, tc)
+	}
+
+	_, _, err = r.Resolve("urgent", "", "")
+	require.Error(t, err)
+
+	r, err = NewResolver(configs.PriorityConfig{Default: "low"})
+	require.NoError(t, err)
+	p, _, err := r.Resolve("normal", "", "")
+	require.NoError(t, err)
+	require.Equal(t, PriorityLow, p)
+	_, err = NewResolver(configs.PriorityConfig{Default: "urgent"})
+	require.Error(t, err)
+	_, err = NewResolver(configs.PriorityConfig{Clients: []configs.ClientPriorityConfig{{ClientCN: "a", Priority: "urgent"}}})
+	require.Error(t, err)
+}
@@ -0,0 +1,125 @@
+package scheduler
+
+import (
+	"context"
+
+	"github.com/iden3/prover-server/pkg/app/configs"
+	"github.com/pkg/errors"
+)
+
+// Priority is a lane of the queue proofs wait in
+type Priority string
+
+// Lanes of the queue, higher lanes are served first
+const (
+	PriorityHigh   Priority = "high"
+	PriorityNormal Priority = "normal"
+	PriorityLow    Priority = "low"
+)
+
+// HeaderPriority is a request header (and gRPC metadata key) carrying requested priority
+const HeaderPriority = "X-Priority"
+
+// HeaderAPIKey is a request header (and gRPC metadata key) carrying API key of the client
+const HeaderAPIKey = "X-API-Key"
+
+// lanes are priorities from the highest one
+var lanes = []Priority{PriorityHigh, PriorityNormal, PriorityLow}
+
+// ParsePriority parses name of the lane
+func ParsePriority(s string) (Priority, error) {
+	for _, p := range lanes {
+		if string(p) == s {
+			return p, nil
+		}
+	}
+	return "", errors.Errorf("unknown priority %q", s)
+}
+
+// Rank is a position of the lane, 0 is the highest one
+func (p Priority) Rank() int {
+	for i, l := range lanes {
+		if l == p {
+			return i
+		}
+	}
+	// unknown priorities are served as normal ones
+	return 1
+}
+
+type priorityKey struct{}
+
+// WithPriority returns context of request with the priority
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFromContext returns priority of the request, false is returned if request has no priority,
+// then default priority of the circuit is used
+func PriorityFromContext(ctx context.Context) (Priority, bool) {
+	p, ok := ctx.Value(priorityKey{}).(Priority)
+	return p, ok
+}
+
+// Resolver resolves priority of requests by requested priority and client
+type Resolver struct {
+	clientCNs map[string]Priority
+	apiKeys   map[string]Priority
+	// unknown is the highest priority clients which aren't configured can request
+	unknown Priority
+}
+
+// NewResolver creates resolver of priorities of clients from config
+func NewResolver(config configs.PriorityConfig) (*Resolver, error) {
+	r := &Resolver{
+		clientCNs: make(map[string]Priority),
+		apiKeys:   make(map[string]Priority),
+		unknown:   PriorityNormal,
+	}
+	if config.Default != "" {
+		p, err := ParsePriority(config.Default)
+		if err != nil {
+			return nil, errors.Wrap(err, "default priority")
+		}
+		r.unknown = p
+	}
+	for i, c := range config.Clients {
+		p, err := ParsePriority(c.Priority)
+		if err != nil {
+			return nil, errors.Wrapf(err, "priority of client #%d", i)
+		}
+		if c.ClientCN != "" {
+			r.clientCNs[c.ClientCN] = p
+		}
+		if c.APIKey != "" {
+			r.apiKeys[c.APIKey] = p
+		}
+	}
+	return r, nil
+}
+
+// Resolve returns priority of request from client with certificate common name clientCN or API key apiKey.
+// Requested priority is used if it's set, but it can't be higher than priority of known client, so that clients
+// of bulk lane can't jump the queue, and clients which aren't configured can't request priority higher than
+// the default one. False is returned if priority isn't set neither by request nor by client.
+func (r *Resolver) Resolve(requested, clientCN, apiKey string) (Priority, bool, error) {
+	client, known := r.apiKeys[apiKey]
+	if !known || apiKey == "" {
+		client, known = r.clientCNs[clientCN]
+		known = known && clientCN != ""
+	}
+	if requested == "" {
+		return client, known, nil
+	}
+	p, err := ParsePriority(requested)
+	if err != nil {
+		return "", false, err
+	}
+	if !known {
+		client = r.unknown
+	}
+	if p.Rank() < client.Rank() {
+		p = client
+	}
+	return p, true, nil
+}
@@ -0,0 +1,263 @@
+package worker
+
+import (
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"os"
+	"os/exec"
+
+	"github.com/iden3/go-rapidsnark/types"
+	"github.com/iden3/prover-server/pkg/app/configs"
+	"github.com/iden3/prover-server/pkg/circuits"
+	"github.com/iden3/prover-server/pkg/log"
+	"github.com/iden3/prover-server/pkg/proof"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrCrashed is returned when worker process exits while generating proof
+	ErrCrashed = errors.New("worker crashed")
+	// ErrClosed is returned when pool is closed
+	ErrClosed = errors.New("worker pool is closed")
+)
+
+// Job is a proof generation request for the pool
+type Job struct {
+	// Circuit is ID of the circuit and Version identifies its loaded artifacts, e.g. time of loading,
+	// location of artifacts is sent to the worker once per version
+	Circuit  string
+	Version  string
+	Location circuits.Location
+	Witness  configs.WitnessConfig
+	Backend  configs.ProverBackendConfig
+	Inputs   proof.ZKInputs
+}
+
+// Pool runs proof generation in worker subprocesses, so that crash of native code fails only the request
+// the worker was processing. Crashed workers are restarted.
+type Pool struct {
+	path string
+	args []string
+	// cacheSize limits size of artifacts cached by each worker, it's a share of the pool's cache size
+	cacheSize int64
+	// idle are processes waiting for requests, nil is a slot of worker which failed to restart
+	idle   chan *process
+	size   int
+	closed chan struct{}
+}
+
+// process is a running worker
+type process struct {
+	cmd       *exec.Cmd
+	requests  *os.File
+	responses *os.File
+	enc       *gob.Encoder
+	dec       *gob.Decoder
+	// versions are versions of circuits loaded by the worker
+	versions  map[string]string
+	cacheSize int64
+	// exited is closed when process exits, state is set then
+	exited chan struct{}
+	state  *os.ProcessState
+}
+
+// NewPool starts size worker processes running "<path> <args...>", which call Serve.
+// Each worker reads artifacts of circuits itself and caches them up to its equal share of cacheSize bytes,
+// so the workers together don't cache more than cacheSize.
+func NewPool(size int, cacheSize int64, path string, args ...string) (*Pool, error) {
+	if size > 0 {
+		cacheSize /= int64(size)
+	}
+	p := &Pool{
+		path:      path,
+		args:      args,
+		cacheSize: cacheSize,
+		idle:      make(chan *process, size),
+		size:      size,
+		closed:    make(chan struct{}),
+	}
+	for i := 0; i < size; i++ {
+		w, err := p.start()
+		if err != nil {
+			for j := 0; j < i; j++ {
+				(<-p.idle).stop()
+			}
+			return nil, err
+		}
+		p.idle <- w
+	}
+	return p, nil
+}
+
+// Generate generates proof in idle worker, it waits for idle worker if all of them are busy.
+// Worker is killed and restarted when context is done before the proof is generated.
+func (p *Pool) Generate(ctx context.Context, job Job) (*types.ZKProof, error) {
+	var w *process
+	select {
+	case w = <-p.idle:
+	case <-p.closed:
+		return nil, ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if w == nil {
+		var err error
+		if w, err = p.start(); err != nil {
+			p.idle <- nil
+			return nil, err
+		}
+	}
+
+	zkp, err := w.generate(ctx, job)
+	if !w.isExited() {
+		p.idle <- w
+		return zkp, err
+	}
+
+	if ctx.Err() == nil {
+		log.WithContext(ctx).Errorw("Worker crashed", "circuit", job.Circuit, "state", w.state.String())
+	}
+	restarted, startErr := p.start()
+	if startErr != nil {
+		// worker is started by the next request
+		log.WithContext(ctx).Errorw("cannot restart worker", "error", startErr)
+	}
+	p.idle <- restarted
+	return zkp, err
+}
+
+// Close stops workers, it waits for workers generating proofs
+func (p *Pool) Close() error {
+	close(p.closed)
+	for i := 0; i < p.size; i++ {
+		if w := <-p.idle; w != nil {
+			w.stop()
+		}
+	}
+	return nil
+}
+
+// start starts worker process with pipes of requests and responses
+func (p *Pool) start() (*process, error) {
+	reqR, reqW, err := os.Pipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create pipe")
+	}
+	respR, respW, err := os.Pipe()
+	if err != nil {
+		reqR.Close()
+		reqW.Close()
+		return nil, errors.Wrap(err, "failed to create pipe")
+	}
+
+	cmd := exec.Command(p.path, p.args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// pipes become file descriptors 3 and 4 of worker
+	cmd.ExtraFiles = []*os.File{reqR, respW}
+	err = cmd.Start()
+	reqR.Close()
+	respW.Close()
+	if err != nil {
+		reqW.Close()
+		respR.Close()
+		return nil, errors.Wrap(err, "failed to start worker")
+	}
+
+	w := &process{
+		cmd:       cmd,
+		requests:  reqW,
+		responses: respR,
+		enc:       gob.NewEncoder(reqW),
+		dec:       gob.NewDecoder(respR),
+		versions:  make(map[string]string),
+		cacheSize: p.cacheSize,
+		exited:    make(chan struct{}),
+	}
+	go func() {
+		_ = cmd.Wait()
+		w.state = cmd.ProcessState
+		close(w.exited)
+	}()
+	return w, nil
+}
+
+// generate sends job to worker and waits for its result, worker is killed if context is done
+func (w *process) generate(ctx context.Context, job Job) (*types.ZKProof, error) {
+	req := request{
+		Circuit:   job.Circuit,
+		Version:   job.Version,
+		CacheSize: w.cacheSize,
+	}
+	if w.versions[job.Circuit] != job.Version {
+		req.Location, req.Witness, req.Backend = &job.Location, job.Witness, job.Backend
+	}
+	var err error
+	if req.Inputs, err = json.Marshal(job.Inputs); err != nil {
+		return nil, errors.Wrap(err, "failed to serialize inputs")
+	}
+
+	var resp response
+	done := make(chan error, 1)
+	go func() {
+		if err := w.enc.Encode(req); err != nil {
+			done <- err
+			return
+		}
+		w.versions[job.Circuit] = job.Version
+		for {
+			resp = response{}
+			if err := w.dec.Decode(&resp); err != nil {
+				done <- err
+				return
+			}
+			if resp.Done {
+				done <- nil
+				return
+			}
+			proof.ReportProgress(ctx, resp.Phase)
+		}
+	}()
+
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		w.kill()
+		<-done
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		// broken pipe means the worker has exited, or it's killed to not leave it in unknown state
+		w.kill()
+		return nil, errors.Wrapf(ErrCrashed, "worker exited with %s", w.state)
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return resp.Proof, nil
+}
+
+// kill kills worker process and waits for it to exit
+func (w *process) kill() {
+	_ = w.cmd.Process.Kill()
+	<-w.exited
+	w.requests.Close()
+	w.responses.Close()
+}
+
+// stop closes requests pipe, so that worker exits after the current request, and waits for it to exit
+func (w *process) stop() {
+	w.requests.Close()
+	<-w.exited
+	w.responses.Close()
+}
+
+func (w *process) isExited() bool {
+	select {
+	case <-w.exited:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,136 @@
+package worker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/iden3/go-rapidsnark/types"
+	"github.com/iden3/prover-server/pkg/circuits"
+	"github.com/iden3/prover-server/pkg/proof"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// testWorkerEnv makes test binary run as worker process
+const testWorkerEnv = "PROVER_TEST_WORKER"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(testWorkerEnv) != "" {
+		if err := serve(os.NewFile(requestsFD, "requests"), os.NewFile(responsesFD, "responses"), testGenerate); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// testGenerate returns zkey of the circuit as public signal, or crashes or hangs depending on inputs
+func testGenerate(ctx context.Context, _ proof.WitnessCalculator, _ proof.Prover,
+	artifacts *proof.Artifacts, inputs proof.ZKInputs) (*types.ZKProof, error) {
+
+	proof.ReportProgress(ctx, proof.PhaseWitness)
+	switch inputs["action"] {
+	case "crash":
+		// signal is delivered asynchronously, the worker mustn't respond before it
+		_ = syscall.Kill(os.Getpid(), syscall.SIGSEGV)
+		time.Sleep(time.Hour)
+	case "hang":
+		time.Sleep(time.Hour)
+	case "fail":
+		return nil, errors.New("illegal inputs")
+	}
+	proof.ReportProgress(ctx, proof.PhaseProof)
+	return &types.ZKProof{Proof: &types.ProofData{Protocol: "groth16"}, PubSignals: []string{string(artifacts.ZKey)}}, nil
+}
+
+func TestPool(t *testing.T) {
+	t.Setenv(testWorkerEnv, "1")
+	p, err := NewPool(1, 1<<20, os.Args[0])
+	require.NoError(t, err)
+
+	basePath := t.TempDir()
+	job := Job{Circuit: "auth", Version: "1", Location: writeCircuit(t, basePath, "v1")}
+	var phases []proof.Phase
+	ctx := proof.WithProgress(context.Background(), func(phase proof.Phase) {
+		phases = append(phases, phase)
+	})
+	zkp, err := p.Generate(ctx, job)
+	require.NoError(t, err)
+	require.Equal(t, []string{"v1"}, zkp.PubSignals)
+	require.Equal(t, []proof.Phase{proof.PhaseWitness, proof.PhaseProof}, phases)
+
+	// worker keeps location and artifacts of loaded version
+	job.Location = writeCircuit(t, basePath, "v2")
+	zkp, err = p.Generate(context.Background(), job)
+	require.NoError(t, err)
+	require.Equal(t, []string{"v1"}, zkp.PubSignals)
+
+	job.Inputs = proof.ZKInputs{"action": "fail"}
+	_, err = p.Generate(context.Background(), job)
+	require.EqualError(t, err, "illegal inputs")
+
+	// crashed worker is restarted and circuit is loaded again
+	job.Inputs = proof.ZKInputs{"action": "crash"}
+	_, err = p.Generate(context.Background(), job)
+	require.ErrorIs(t, err, ErrCrashed)
+
+	job.Inputs = nil
+	job.Version = "2"
+	zkp, err = p.Generate(context.Background(), job)
+	require.NoError(t, err)
+	require.Equal(t, []string{"v2"}, zkp.PubSignals)
+
+	// hanging worker is killed when request is cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	job.Inputs = proof.ZKInputs{"action": "hang"}
+	_, err = p.Generate(ctx, job)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	job.Inputs = nil
+	zkp, err = p.Generate(context.Background(), job)
+	require.NoError(t, err)
+	require.Equal(t, []string{"v2"}, zkp.PubSignals)
+
+	require.NoError(t, p.Close())
+	_, err = p.Generate(context.Background(), job)
+	require.ErrorIs(t, err, ErrClosed)
+}
+
+// writeCircuit writes circuit with the zkey into directory named after it and returns its location
+func TestPoolSplitsCache(t *testing.T) {
+	t.Setenv(testWorkerEnv, "1")
+	p, err := NewPool(2, 4<<20, os.Args[0])
+	require.NoError(t, err)
+	defer p.Close()
+
+	for i := 0; i < 2; i++ {
+		w := <-p.idle
+		require.Equal(t, int64(2<<20), w.cacheSize)
+		p.idle <- w
+	}
+}
+
+func writeCircuit(t *testing.T, basePath, zkey string) circuits.Location {
+	t.Helper()
+
+	loc := circuits.Location{Path: filepath.Join(basePath, zkey), Files: proof.DefaultArtifactFiles}
+	hashes := []*string{&loc.Hashes.Wasm, &loc.Hashes.ZKey, &loc.Hashes.VerificationKey}
+	require.NoError(t, os.Mkdir(loc.Path, 0o755))
+	for i, name := range loc.Files.List() {
+		content := []byte(name)
+		if name == loc.Files.ZKey {
+			content = []byte(zkey)
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(loc.Path, name), content, 0o600))
+		sum := sha256.Sum256(content)
+		*hashes[i] = hex.EncodeToString(sum[:])
+	}
+	return loc
+}
@@ -0,0 +1,131 @@
+package worker
+
+import (
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/iden3/go-rapidsnark/types"
+	"github.com/iden3/prover-server/pkg/app/configs"
+	"github.com/iden3/prover-server/pkg/circuits"
+	"github.com/iden3/prover-server/pkg/proof"
+	"github.com/pkg/errors"
+)
+
+// Command is a subcommand of the server binary which runs worker process
+const Command = "worker"
+
+// File descriptors of pipes the pool passes to worker process
+const (
+	requestsFD  = 3
+	responsesFD = 4
+)
+
+// request is a request for proof generation sent to worker
+type request struct {
+	// Circuit is ID of the circuit and Version identifies its loaded artifacts
+	Circuit string
+	Version string
+	// Location, Witness and Backend are sent with the first request of circuit version to the worker
+	Location *circuits.Location
+	Witness  configs.WitnessConfig
+	Backend  configs.ProverBackendConfig
+	// CacheSize limits size of artifacts cached by the worker
+	CacheSize int64
+	// Inputs are JSON encoded inputs, which can't be encoded with gob as interface values
+	Inputs []byte
+}
+
+// response is a phase of proof generation entered by worker, or result of request if Done is set
+type response struct {
+	Phase proof.Phase
+	Done  bool
+	Proof *types.ZKProof
+	Error string
+}
+
+// GenerateFunc generates proof, it's proof.Generate in worker process
+type GenerateFunc func(ctx context.Context, calc proof.WitnessCalculator, p proof.Prover,
+	artifacts *proof.Artifacts, inputs proof.ZKInputs) (*types.ZKProof, error)
+
+// loadedCircuit is a version of circuit kept by worker, its artifacts are read through cache of the worker
+type loadedCircuit struct {
+	version  string
+	location circuits.Location
+	calc     proof.WitnessCalculator
+	prover   proof.Prover
+	err      error
+}
+
+// Serve runs worker loop on pipes passed by the pool, it returns when the pool closes requests pipe
+func Serve() error {
+	return serve(os.NewFile(requestsFD, "requests"), os.NewFile(responsesFD, "responses"), proof.Generate)
+}
+
+// serve generates proofs for requests read from r and writes responses to w one by one
+func serve(r io.Reader, w io.Writer, generate GenerateFunc) error {
+	dec := gob.NewDecoder(r)
+	enc := gob.NewEncoder(w)
+	loaded := make(map[string]*loadedCircuit)
+	var cache *circuits.ArtifactCache
+	for {
+		var req request
+		if err := dec.Decode(&req); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return errors.Wrap(err, "failed to read request")
+		}
+
+		if cache == nil {
+			cache = circuits.NewArtifactCache(req.CacheSize)
+		}
+		c := loaded[req.Circuit]
+		if req.Location != nil {
+			// previous version of the circuit is replaced
+			c = &loadedCircuit{version: req.Version, location: *req.Location}
+			c.calc, c.err = proof.NewWitnessCalculator(req.Witness)
+			if c.err == nil {
+				c.prover, c.err = proof.NewProver(req.Backend)
+			}
+			loaded[req.Circuit] = c
+		}
+
+		var writeErr error
+		ctx := proof.WithProgress(context.Background(), func(phase proof.Phase) {
+			if writeErr == nil {
+				writeErr = enc.Encode(response{Phase: phase})
+			}
+		})
+		zkp, err := func() (*types.ZKProof, error) {
+			if c == nil || c.version != req.Version {
+				return nil, errors.Errorf("circuit %s isn't loaded by worker", req.Circuit)
+			}
+			if c.err != nil {
+				return nil, c.err
+			}
+			var inputs proof.ZKInputs
+			if err := json.Unmarshal(req.Inputs, &inputs); err != nil {
+				return nil, errors.Wrap(err, "failed to parse inputs")
+			}
+			artifacts, err := cache.Get(ctx, c.location, c.prover)
+			if err != nil {
+				return nil, err
+			}
+			return generate(ctx, c.calc, c.prover, artifacts, inputs)
+		}()
+
+		resp := response{Done: true, Proof: zkp}
+		if err != nil {
+			resp.Error = err.Error()
+		}
+		if writeErr != nil {
+			return errors.Wrap(writeErr, "failed to write response")
+		}
+		if err = enc.Encode(resp); err != nil {
+			return errors.Wrap(err, "failed to write response")
+		}
+	}
+}